@@ -3,11 +3,25 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"cuelang.org/go/cue"
+	cuejson "cuelang.org/go/encoding/json"
 )
 
 // IntoCueValue is a function that attempts to convert a given value into a cue.Value.
+// The value goes through JSON, as YAML files do through CUE, so that integral numbers are CUE integers
+// (rather than the floats Go decodes them into), and values from every source unify.
 func IntoCueValue(cueCtx *cue.Context, v any) (*cue.Value, error) {
-	value := cueCtx.Encode(v)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	expr, err := cuejson.Extract("", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract value: %w", err)
+	}
+	value := cueCtx.BuildExpr(expr)
 	return &value, value.Err()
 }
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// ExemptAnnotation is the annotation exempting a stream item from functions (identified by the name of
+	// their config) or policies (identified by their name), as a comma-separated list.
+	ExemptAnnotation = "config.cuestomize.io/exempt"
+	// ExemptReasonAnnotation is the annotation holding the reason of the exemption, which is required.
+	ExemptReasonAnnotation = "config.cuestomize.io/exempt-reason"
+)
+
+// Exemption is the exemption of a stream item from functions or policies.
+type Exemption struct {
+	// Targets are the names of the function configs and of the policies the item is exempted from.
+	Targets []string
+	// Reason is why the item is exempted.
+	Reason string
+}
+
+// GetExemption returns the exemption of the item, or nil if the item is not exempted.
+// It returns an error if the item is exempted without a reason.
+func GetExemption(item *kyaml.RNode) (*Exemption, error) {
+	annotations := item.GetAnnotations()
+	value, ok := annotations[ExemptAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	exemption := &Exemption{Reason: strings.TrimSpace(annotations[ExemptReasonAnnotation])}
+	for _, target := range strings.Split(value, ",") {
+		if target = strings.TrimSpace(target); target != "" {
+			exemption.Targets = append(exemption.Targets, target)
+		}
+	}
+	if len(exemption.Targets) == 0 {
+		return nil, fmt.Errorf("annotation '%s' of %s/%s does not name any function or policy", ExemptAnnotation, item.GetKind(), item.GetName())
+	}
+	if exemption.Reason == "" {
+		return nil, fmt.Errorf("%s/%s is exempted without a reason, annotation '%s' is required", item.GetKind(), item.GetName(), ExemptReasonAnnotation)
+	}
+	return exemption, nil
+}
+
+// Exempts tells whether the exemption covers the function config or the policy with the given name.
+// An empty name is never exempted from.
+func (e *Exemption) Exempts(name string) bool {
+	if e == nil || name == "" {
+		return false
+	}
+	for _, target := range e.Targets {
+		if target == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExempted tells whether the item is exempted from the function config, i.e. whether the annotation of the
+// item names the config.
+func (i *KRMInput) IsExempted(item *kyaml.RNode) (bool, error) {
+	exemption, err := GetExemption(item)
+	if err != nil {
+		return false, err
+	}
+	return exemption.Exempts(i.Name), nil
+}
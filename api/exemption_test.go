@@ -0,0 +1,92 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestGetExemption(t *testing.T) {
+	tests := []struct {
+		name           string
+		annotations    map[string]string
+		expected       *Exemption
+		errorSubstring string
+	}{
+		{
+			name: "not exempted",
+		},
+		{
+			name: "exempted from several targets",
+			annotations: map[string]string{
+				ExemptAnnotation:       "validator, replicas ,",
+				ExemptReasonAnnotation: "legacy deployment, see JIRA-123",
+			},
+			expected: &Exemption{Targets: []string{"validator", "replicas"}, Reason: "legacy deployment, see JIRA-123"},
+		},
+		{
+			name:           "without reason",
+			annotations:    map[string]string{ExemptAnnotation: "validator"},
+			errorSubstring: "Deployment/app is exempted without a reason, annotation 'config.cuestomize.io/exempt-reason' is required",
+		},
+		{
+			name:           "without target",
+			annotations:    map[string]string{ExemptAnnotation: " ", ExemptReasonAnnotation: "legacy"},
+			errorSubstring: "does not name any function or policy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			item := kyaml.MustParse("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\n")
+			if tt.annotations != nil {
+				require.NoError(t, item.SetAnnotations(tt.annotations))
+			}
+
+			exemption, err := GetExemption(item)
+
+			if tt.errorSubstring != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, exemption)
+		})
+	}
+}
+
+func TestExtractIncludes_Exempted(t *testing.T) {
+	items := []*kyaml.RNode{
+		kyaml.MustParse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: legacy
+  namespace: app
+  annotations:
+    config.cuestomize.io/exempt: validator
+    config.cuestomize.io/exempt-reason: legacy
+`),
+		kyaml.MustParse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: app
+`),
+	}
+	krm := &KRMInput{
+		ObjectMeta: metav1.ObjectMeta{Name: "validator"},
+		Includes:   []IncludeSelector{{Selector: types.Selector{ResId: resid.NewResIdKindOnly("Deployment", "")}}},
+	}
+
+	includes, err := ExtractIncludes(t.Context(), krm, items)
+
+	require.NoError(t, err)
+	require.Contains(t, includes["apps/v1"]["Deployment"]["app"], "app")
+	require.NotContains(t, includes["apps/v1"]["Deployment"]["app"], "legacy")
+}
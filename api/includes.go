@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"cuelang.org/go/cue"
 	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
@@ -27,6 +29,84 @@ func (i Includes) Add(include *kyaml.RNode) error {
 	return nil
 }
 
+// SensitiveValues returns the values of the included Secrets, i.e. the values of their stringData, including
+// the ones added by decoding their data, and the values of their data, both as included (base64-encoded) and
+// decoded. Such values should not appear in errors nor logs.
+func (i Includes) SensitiveValues() []string {
+	var values []string
+	for _, kinds := range i {
+		for _, namespaces := range kinds["Secret"] {
+			for _, obj := range namespaces {
+				secret, ok := obj.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				stringData, _ := secret["stringData"].(map[string]interface{})
+				for _, v := range stringData {
+					if s, ok := v.(string); ok {
+						values = append(values, s)
+					}
+				}
+				data, _ := secret["data"].(map[string]interface{})
+				for _, v := range data {
+					s, ok := v.(string)
+					if !ok {
+						continue
+					}
+					values = append(values, s)
+					if decoded, err := base64.StdEncoding.DecodeString(s); err == nil {
+						values = append(values, string(decoded))
+					}
+				}
+			}
+		}
+	}
+	return uniqueValues(values)
+}
+
+// uniqueValues returns the non-empty values, sorted and without duplicates.
+func uniqueValues(values []string) []string {
+	values = slices.DeleteFunc(values, func(v string) bool { return v == "" })
+	slices.Sort(values)
+	return slices.Compact(values)
+}
+
+// decode adds to an already added include the decoded view of its data, as requested by the selector.
+// Secrets get their data decoded into stringData (existing stringData values take precedence), while
+// ConfigMaps get their binaryData decoded into data.
+func (i Includes) decode(include *kyaml.RNode, sel *IncludeSelector) error {
+	var from, into string
+	switch {
+	case include.GetKind() == "Secret" && sel.DecodeSecrets:
+		from, into = "data", "stringData"
+	case include.GetKind() == "ConfigMap" && sel.DecodeBinaryData:
+		from, into = "binaryData", "data"
+	default:
+		return nil
+	}
+
+	values, err := decodedFieldMap(include, from)
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	obj, _ := i[include.GetApiVersion()][include.GetKind()][include.GetNamespace()][include.GetName()].(map[string]interface{})
+	decoded, _ := obj[into].(map[string]interface{})
+	if decoded == nil {
+		decoded = make(map[string]interface{}, len(values))
+	}
+	for k, v := range values {
+		if _, ok := decoded[k]; !ok {
+			decoded[k] = v
+		}
+	}
+	obj[into] = decoded
+	return nil
+}
+
 func (i Includes) initialiseMap(include *kyaml.RNode) {
 	apiVersion := include.GetApiVersion()
 	kind := include.GetKind()
@@ -5,6 +5,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/resid"
 	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
@@ -168,3 +170,81 @@ func createTestNode(t *testing.T, apiVersion, kind, namespace, name string) *kya
 	require.NoError(t, err)
 	return node
 }
+
+func TestExtractIncludes_Decode(t *testing.T) {
+	secret := kyaml.MustParse(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+  namespace: default
+data:
+  password: c2VjcmV0
+  username: YWRtaW4=
+stringData:
+  username: root
+`)
+	configMap := kyaml.MustParse(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: files
+  namespace: default
+data:
+  plain: text
+binaryData:
+  banner: aGVsbG8=
+`)
+
+	tests := []struct {
+		name             string
+		selector         IncludeSelector
+		expectedData     map[string]interface{}
+		expectedField    string
+		expectedKind     string
+		expectedName     string
+		expectedRedacted []string
+	}{
+		{
+			name:             "secret without decoding",
+			selector:         IncludeSelector{Selector: types.Selector{ResId: resid.NewResIdKindOnly("Secret", "credentials")}},
+			expectedKind:     "Secret",
+			expectedName:     "credentials",
+			expectedField:    "stringData",
+			expectedData:     map[string]interface{}{"username": "root"},
+			expectedRedacted: []string{"root", "c2VjcmV0", "secret", "YWRtaW4=", "admin"},
+		},
+		{
+			name:             "secret with decoding",
+			selector:         IncludeSelector{Selector: types.Selector{ResId: resid.NewResIdKindOnly("Secret", "credentials")}, DecodeSecrets: true},
+			expectedKind:     "Secret",
+			expectedName:     "credentials",
+			expectedField:    "stringData",
+			expectedData:     map[string]interface{}{"username": "root", "password": "secret"},
+			expectedRedacted: []string{"root", "c2VjcmV0", "secret", "YWRtaW4=", "admin"},
+		},
+		{
+			name:          "configmap with binaryData decoding",
+			selector:      IncludeSelector{Selector: types.Selector{ResId: resid.NewResIdKindOnly("ConfigMap", "files")}, DecodeBinaryData: true},
+			expectedKind:  "ConfigMap",
+			expectedName:  "files",
+			expectedField: "data",
+			expectedData:  map[string]interface{}{"plain": "text", "banner": "hello"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			krm := &KRMInput{Includes: []IncludeSelector{tt.selector}}
+
+			includes, err := ExtractIncludes(t.Context(), krm, []*kyaml.RNode{secret, configMap})
+			require.NoError(t, err)
+
+			obj, ok := includes["v1"][tt.expectedKind]["default"][tt.expectedName].(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, tt.expectedData, obj[tt.expectedField])
+			assert.ElementsMatch(t, tt.expectedRedacted, includes.SensitiveValues())
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Workday/cuestomize/pkg/cuerrors"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// InputFrom selects a ConfigMap or a Secret from the kustomize stream whose data is merged into the model input.
+type InputFrom struct {
+	types.Selector `json:",inline" yaml:",inline"`
+
+	// Path is the CUE path, relative to the input, at which the data is merged.
+	// If empty, the data is merged at the root of the input.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Parse tells whether each data value must be parsed as YAML (or JSON) instead of being passed as a string.
+	Parse bool `yaml:"parse,omitempty" json:"parse,omitempty"`
+	// Optional tells whether it is acceptable for the selector not to match any item.
+	Optional bool `yaml:"optional,omitempty" json:"optional,omitempty"`
+}
+
+// InputSource holds the data extracted from a stream item selected by an InputFrom entry.
+type InputSource struct {
+	// ID identifies the item the data was extracted from.
+	ID resid.ResId
+	// Path is the CUE path, relative to the input, at which the data must be merged.
+	Path string
+	// Data is the decoded (and optionally parsed) data of the item.
+	Data map[string]interface{}
+}
+
+// ExtractInputFrom searches items for matches against the inputFrom entries of the KRMInput
+// and returns the data of every matched item, in the order the entries are declared.
+func ExtractInputFrom(ctx context.Context, krm *KRMInput, items []*kyaml.RNode) ([]InputSource, error) {
+	log := logr.FromContextOrDiscard(ctx)
+
+	var sources []InputSource
+	for _, from := range krm.InputFrom {
+		if from.Kind != "ConfigMap" && from.Kind != "Secret" {
+			return nil, fmt.Errorf(`inputFrom kind must be ConfigMap or Secret, got: "%s"`, from.Kind)
+		}
+
+		matchCount := 0
+		for _, item := range items {
+			matches, err := ItemMatchReference(item, &from.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("failed to match item against selector [%v]: %w", from.Selector.String(), err)
+			}
+			if !matches {
+				continue
+			}
+			// the resources generated on a previous run are pruned, and must not be fed back into the model
+			if krm.Owns(item) {
+				log.V(4).Info("skipping inputFrom item owned by the function", "selector", from.Selector.String(),
+					"kind", item.GetKind(), "apiVersion", item.GetApiVersion(), "namespace", item.GetNamespace(), "name", item.GetName())
+				continue
+			}
+			matchCount++
+
+			data, err := itemData(item, from.Parse)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read data of %s: %w", resid.FromRNode(item), err)
+			}
+			sources = append(sources, InputSource{ID: resid.FromRNode(item), Path: from.Path, Data: data})
+		}
+
+		if matchCount == 0 {
+			if !from.Optional {
+				return nil, fmt.Errorf("no items matched for inputFrom selector [%s]", from.Selector.String())
+			}
+			log.V(-1).Info("no items matched for optional inputFrom selector", "selector", from.Selector.String())
+		}
+	}
+
+	return sources, nil
+}
+
+// SensitiveInputValues returns the values of the data extracted from Secrets (base64-decoded, and parsed
+// if requested, in which case every scalar of the parsed data is returned). Such values should not appear
+// in errors nor logs.
+func SensitiveInputValues(sources []InputSource) []string {
+	var values []string
+	for _, src := range sources {
+		if src.ID.Kind != "Secret" {
+			continue
+		}
+		for _, v := range src.Data {
+			values = appendScalars(values, v)
+		}
+	}
+	return uniqueValues(values)
+}
+
+// appendScalars appends to values the scalars of v, formatted as strings, walking maps and lists.
+func appendScalars(values []string, v interface{}) []string {
+	switch v := v.(type) {
+	case nil:
+		return values
+	case map[string]interface{}:
+		for _, e := range v {
+			values = appendScalars(values, e)
+		}
+		return values
+	case []interface{}:
+		for _, e := range v {
+			values = appendScalars(values, e)
+		}
+		return values
+	case string:
+		return append(values, v)
+	default:
+		return append(values, fmt.Sprint(v))
+	}
+}
+
+// itemData returns the data of a ConfigMap or a Secret, with base64-encoded values decoded.
+// If parse is true, each value is parsed as YAML (JSON being a subset of it), keeping integers as ints,
+// so that they unify with the integers of the model.
+func itemData(item *kyaml.RNode, parse bool) (map[string]interface{}, error) {
+	raw := make(map[string]string)
+
+	var plainField, encodedField string
+	switch item.GetKind() {
+	case "ConfigMap":
+		plainField, encodedField = "data", "binaryData"
+	case "Secret":
+		plainField, encodedField = "stringData", "data"
+	default:
+		return nil, fmt.Errorf(`kind must be ConfigMap or Secret, got: "%s"`, item.GetKind())
+	}
+
+	decoded, err := decodedFieldMap(item, encodedField)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range decoded {
+		raw[k] = v
+	}
+	// plain values take precedence, as stringData does over data in Secrets
+	for k, v := range fieldMap(item, plainField) {
+		raw[k] = v
+	}
+
+	// the values of Secrets are redacted from the parse errors, as they may quote the offending value
+	var detailer cuerrors.Detailer = cuerrors.EmptyDetailer{}
+	if item.GetKind() == "Secret" {
+		values := make([]string, 0, len(raw))
+		for _, v := range raw {
+			values = append(values, v)
+		}
+		detailer = cuerrors.NewRedactingDetailer(detailer, values)
+	}
+
+	data := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		if !parse {
+			data[k] = v
+			continue
+		}
+		var parsed interface{}
+		if err := kyaml.Unmarshal([]byte(v), &parsed); err != nil {
+			return nil, detailer.ErrorWithDetails(err, "failed to parse key '%s'", k)
+		}
+		data[k] = parsed
+	}
+	return data, nil
+}
+
+// fieldMap returns the string map stored at the given top-level field of the item, if any.
+func fieldMap(item *kyaml.RNode, field string) map[string]string {
+	node := item.Field(field)
+	if node == nil || node.Value == nil {
+		return nil
+	}
+	m := make(map[string]string)
+	_ = node.Value.VisitFields(func(f *kyaml.MapNode) error {
+		m[f.Key.YNode().Value] = f.Value.YNode().Value
+		return nil
+	})
+	return m
+}
+
+// decodedFieldMap returns the string map stored at the given top-level field of the item, if any,
+// with its values base64-decoded.
+func decodedFieldMap(item *kyaml.RNode, field string) (map[string]string, error) {
+	m := fieldMap(item, field)
+	for k, v := range m {
+		decoded, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key '%s' of '%s': %w", k, field, err)
+		}
+		m[k] = string(decoded)
+	}
+	return m, nil
+}
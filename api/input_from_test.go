@@ -0,0 +1,161 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestExtractInputFrom(t *testing.T) {
+	configMap := kyaml.MustParse(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: values
+  namespace: default
+data:
+  replicas: "3"
+  labels: '{"app": "web"}'
+binaryData:
+  banner: aGVsbG8=
+`)
+	secret := kyaml.MustParse(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+  namespace: default
+data:
+  password: c2VjcmV0
+  username: YWRtaW4=
+stringData:
+  username: root
+`)
+
+	selectorFor := func(kind, name string) types.Selector {
+		return types.Selector{ResId: resid.NewResIdWithNamespace(resid.Gvk{Version: "v1", Kind: kind}, name, "default")}
+	}
+
+	tests := []struct {
+		name           string
+		inputFrom      []InputFrom
+		expected       []InputSource
+		errorSubstring string
+	}{
+		{
+			name:      "configmap data and binaryData as strings",
+			inputFrom: []InputFrom{{Selector: selectorFor("ConfigMap", "values")}},
+			expected: []InputSource{{
+				ID:   resid.FromRNode(configMap),
+				Data: map[string]interface{}{"replicas": "3", "labels": `{"app": "web"}`, "banner": "hello"},
+			}},
+		},
+		{
+			name:      "configmap data parsed at path",
+			inputFrom: []InputFrom{{Selector: selectorFor("ConfigMap", "values"), Path: "app", Parse: true}},
+			expected: []InputSource{{
+				ID:   resid.FromRNode(configMap),
+				Path: "app",
+				Data: map[string]interface{}{"replicas": 3, "labels": map[string]interface{}{"app": "web"}, "banner": "hello"},
+			}},
+		},
+		{
+			name:      "secret data decoded with stringData taking precedence",
+			inputFrom: []InputFrom{{Selector: selectorFor("Secret", "credentials")}},
+			expected: []InputSource{{
+				ID:   resid.FromRNode(secret),
+				Data: map[string]interface{}{"username": "root", "password": "secret"},
+			}},
+		},
+		{
+			name:      "optional selector matching nothing",
+			inputFrom: []InputFrom{{Selector: selectorFor("ConfigMap", "missing"), Optional: true}},
+		},
+		{
+			name:           "required selector matching nothing",
+			inputFrom:      []InputFrom{{Selector: selectorFor("ConfigMap", "missing")}},
+			errorSubstring: "no items matched for inputFrom selector",
+		},
+		{
+			name:           "unsupported kind",
+			inputFrom:      []InputFrom{{Selector: selectorFor("Service", "values")}},
+			errorSubstring: "inputFrom kind must be ConfigMap or Secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			krm := &KRMInput{InputFrom: tt.inputFrom}
+
+			sources, err := ExtractInputFrom(t.Context(), krm, []*kyaml.RNode{configMap, secret})
+
+			if tt.errorSubstring != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, sources)
+		})
+	}
+}
+
+func TestSensitiveInputValues(t *testing.T) {
+	secret := kyaml.MustParse(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: database
+  namespace: default
+data:
+  # {"user": "app", "password": "hunter22", "port": 5432}
+  config: eyJ1c2VyIjogImFwcCIsICJwYXNzd29yZCI6ICJodW50ZXIyMiIsICJwb3J0IjogNTQzMn0=
+stringData:
+  token: t0k3n
+`)
+	configMap := kyaml.MustParse(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: values
+  namespace: default
+data:
+  replicas: "3"
+`)
+	selectorFor := func(kind, name string) types.Selector {
+		return types.Selector{ResId: resid.NewResIdWithNamespace(resid.Gvk{Version: "v1", Kind: kind}, name, "default")}
+	}
+
+	tests := []struct {
+		name      string
+		inputFrom []InputFrom
+		expected  []string
+	}{
+		{
+			name:      "decoded secret data",
+			inputFrom: []InputFrom{{Selector: selectorFor("Secret", "database")}, {Selector: selectorFor("ConfigMap", "values")}},
+			expected:  []string{"t0k3n", `{"user": "app", "password": "hunter22", "port": 5432}`},
+		},
+		{
+			name:      "scalars of parsed secret data",
+			inputFrom: []InputFrom{{Selector: selectorFor("Secret", "database"), Parse: true}},
+			expected:  []string{"5432", "app", "hunter22", "t0k3n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			krm := &KRMInput{InputFrom: tt.inputFrom}
+
+			sources, err := ExtractInputFrom(t.Context(), krm, []*kyaml.RNode{secret, configMap})
+			require.NoError(t, err)
+
+			require.Equal(t, tt.expected, SensitiveInputValues(sources))
+		})
+	}
+}
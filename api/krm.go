@@ -22,14 +22,53 @@ type KRMInput struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	// Input contains the KRM input specification.
-	Input        map[string]interface{} `yaml:"input" json:"input"`
-	Includes     []types.Selector       `yaml:"includes,omitempty" json:"includes,omitempty"`
-	RemoteModule *RemoteModule          `yaml:"remoteModule,omitempty" json:"remoteModule,omitempty"`
+	Input map[string]interface{} `yaml:"input" json:"input"`
+	// InputFiles lists YAML (or JSON) files, relative to the CUE model path, that are unified into the input.
+	InputFiles []string `yaml:"inputFiles,omitempty" json:"inputFiles,omitempty"`
+	// InputFrom selects ConfigMaps and Secrets from the stream whose data is merged into the input.
+	InputFrom    []InputFrom       `yaml:"inputFrom,omitempty" json:"inputFrom,omitempty"`
+	Includes     []IncludeSelector `yaml:"includes,omitempty" json:"includes,omitempty"`
+	RemoteModule *RemoteModule     `yaml:"remoteModule,omitempty" json:"remoteModule,omitempty"`
+	// Dir is the directory of the CUE model to load, relative to the root of the CUE module (default: the root).
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Package is the CUE package to load from the directory, required if the directory holds several packages.
+	Package string `yaml:"package,omitempty" json:"package,omitempty"`
+	// Tags are injected, by name, into the fields of the CUE model with a @tag(name) attribute.
+	Tags map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	// BuildTags are the tags satisfying the @if(tag) build constraints of the files of the CUE model.
+	BuildTags []string `yaml:"buildTags,omitempty" json:"buildTags,omitempty"`
+	// MergePolicy defines how outputs with the same resource ID as an item of the stream are handled.
+	MergePolicy MergePolicy `yaml:"mergePolicy,omitempty" json:"mergePolicy,omitempty"`
+	// Provenance configures the annotations recording where the generated resources come from.
+	Provenance *Provenance `yaml:"provenance,omitempty" json:"provenance,omitempty"`
+	// OutputOrder defines the order in which the outputs are added to the stream.
+	OutputOrder OutputOrder `yaml:"outputOrder,omitempty" json:"outputOrder,omitempty"`
+	// Paths overrides the CUE paths used to exchange data with the model.
+	Paths *Paths `yaml:"paths,omitempty" json:"paths,omitempty"`
+	// SchemaValidation configures the validation of the generated resources against the Kubernetes schemas.
+	SchemaValidation *SchemaValidation `yaml:"schemaValidation,omitempty" json:"schemaValidation,omitempty"`
+	// Ownership configures the marking of the generated resources, so that re-runs replace them instead of duplicating them.
+	Ownership *Ownership `yaml:"ownership,omitempty" json:"ownership,omitempty"`
+	// PathTemplate is the template of the file path the generated resources are written to,
+	// unless the model sets it (e.g. {{namespace}}/{{kind}}-{{name}}.yaml).
+	PathTemplate PathTemplate `yaml:"pathTemplate,omitempty" json:"pathTemplate,omitempty"`
+}
+
+// IncludeSelector selects items from the kustomize stream to be included in the CUE model.
+type IncludeSelector struct {
+	types.Selector `json:",inline" yaml:",inline"`
+
+	// DecodeSecrets adds to the included Secrets a stringData view of their base64-decoded data.
+	DecodeSecrets bool `yaml:"decodeSecrets,omitempty" json:"decodeSecrets,omitempty"`
+	// DecodeBinaryData adds to the data of the included ConfigMaps their base64-decoded binaryData.
+	DecodeBinaryData bool `yaml:"decodeBinaryData,omitempty" json:"decodeBinaryData,omitempty"`
+	// Consume removes the matched items from the output stream, so that the model can replace them.
+	Consume bool `yaml:"consume,omitempty" json:"consume,omitempty"`
 }
 
 // ExtractIncludes populates the includes structure from the provided KRMInput and items.
 // It searches items for matches against the includes defined in the KRMInput's spec
-// and returns the includes map.
+// and returns the includes map. Items exempted from the function config are not included.
 func ExtractIncludes(ctx context.Context, krm *KRMInput, items []*kyaml.RNode) (Includes, error) {
 	log := logr.FromContextOrDiscard(ctx)
 
@@ -38,15 +77,34 @@ func ExtractIncludes(ctx context.Context, krm *KRMInput, items []*kyaml.RNode) (
 	for _, sel := range krm.Includes {
 		includesCount := 0
 		for _, item := range items {
-			itemMatches, err := ItemMatchReference(item, &sel)
+			itemMatches, err := ItemMatchReference(item, &sel.Selector)
 			if err != nil {
 				return nil, fmt.Errorf("failed to match item against selector [%v]: %w", sel.String(), err)
 			}
-			if itemMatches {
-				includesCount++
-				if err := includes.Add(item); err != nil {
-					return nil, fmt.Errorf("failed to add include: %w", err)
-				}
+			if !itemMatches {
+				continue
+			}
+			// the resources generated on a previous run are pruned, and must not be fed back into the model
+			if krm.Owns(item) {
+				log.V(4).Info("skipping include owned by the function", "selector", sel.String(),
+					"kind", item.GetKind(), "apiVersion", item.GetApiVersion(), "namespace", item.GetNamespace(), "name", item.GetName())
+				continue
+			}
+			exempted, err := krm.IsExempted(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exemption: %w", err)
+			}
+			if exempted {
+				log.V(4).Info("skipping include exempted from the function", "selector", sel.String(),
+					"kind", item.GetKind(), "apiVersion", item.GetApiVersion(), "namespace", item.GetNamespace(), "name", item.GetName())
+				continue
+			}
+			includesCount++
+			if err := includes.Add(item); err != nil {
+				return nil, fmt.Errorf("failed to add include: %w", err)
+			}
+			if err := includes.decode(item, &sel); err != nil {
+				return nil, fmt.Errorf("failed to decode include: %w", err)
 			}
 		}
 		if includesCount == 0 {
@@ -57,11 +115,44 @@ func ExtractIncludes(ctx context.Context, krm *KRMInput, items []*kyaml.RNode) (
 	return includes, nil
 }
 
+// Validate makes KRMInput implement the framework.Validator interface, so that invalid
+// configurations are reported when the function config is loaded.
+func (i *KRMInput) Validate() error {
+	if err := i.MergePolicy.Validate(); err != nil {
+		return fmt.Errorf("invalid mergePolicy: %w", err)
+	}
+	if err := i.OutputOrder.Validate(); err != nil {
+		return fmt.Errorf("invalid outputOrder: %w", err)
+	}
+	if err := i.PathTemplate.Validate(); err != nil {
+		return fmt.Errorf("invalid pathTemplate: %w", err)
+	}
+	if i.Provenance != nil {
+		if err := i.Provenance.Validate(); err != nil {
+			return fmt.Errorf("invalid provenance: %w", err)
+		}
+	}
+	if i.SchemaValidation != nil {
+		if err := i.SchemaValidation.Validate(); err != nil {
+			return fmt.Errorf("invalid schemaValidation: %w", err)
+		}
+	}
+	if i.Ownership != nil {
+		if err := i.Ownership.Validate(i.Name); err != nil {
+			return fmt.Errorf("invalid ownership: %w", err)
+		}
+	}
+	return nil
+}
+
 // IntoCueValue tries to convert the KRMInput into a CUE value.
 // The method will not convert the whole KRMInput to a CUE value, but only the Input field.
 // This is because the KRMInput part that needs to be passed to the CUE model is entirely
-// contained in the Input field.
+// contained in the Input field. A missing input is an empty struct.
 func (i *KRMInput) IntoCueValue(cueCtx *cue.Context) (*cue.Value, error) {
+	if i.Input == nil {
+		return IntoCueValue(cueCtx, map[string]interface{}{})
+	}
 	return IntoCueValue(cueCtx, i.Input)
 }
 
@@ -0,0 +1,41 @@
+package api
+
+import "fmt"
+
+// MergePolicy defines how a model output is handled when an item with the same resource ID
+// (apiVersion, kind, namespace and name) is already in the stream.
+type MergePolicy string
+
+const (
+	// MergePolicyError makes the function fail when an output has the same resource ID as an item.
+	MergePolicyError MergePolicy = "error"
+	// MergePolicyReplace replaces the item with the output.
+	MergePolicyReplace MergePolicy = "replace"
+	// MergePolicyStrategicMerge merges the output onto the item with a Kubernetes strategic merge.
+	MergePolicyStrategicMerge MergePolicy = "strategic-merge"
+	// MergePolicyJSONMerge merges the output onto the item with a JSON merge patch (RFC 7386).
+	MergePolicyJSONMerge MergePolicy = "json-merge"
+)
+
+// DefaultMergePolicy is the merge policy used when none is configured.
+const DefaultMergePolicy = MergePolicyError
+
+// Validate returns an error if the merge policy is not one of the supported ones.
+// An empty merge policy is valid, and stands for DefaultMergePolicy.
+func (p MergePolicy) Validate() error {
+	switch p {
+	case "", MergePolicyError, MergePolicyReplace, MergePolicyStrategicMerge, MergePolicyJSONMerge:
+		return nil
+	default:
+		return fmt.Errorf(`unsupported merge policy "%s", must be one of: %s, %s, %s, %s`, p,
+			MergePolicyError, MergePolicyReplace, MergePolicyStrategicMerge, MergePolicyJSONMerge)
+	}
+}
+
+// OrDefault returns the merge policy, or DefaultMergePolicy if it is empty.
+func (p MergePolicy) OrDefault() MergePolicy {
+	if p == "" {
+		return DefaultMergePolicy
+	}
+	return p
+}
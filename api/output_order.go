@@ -0,0 +1,28 @@
+package api
+
+import "fmt"
+
+// OutputOrder defines the order in which the outputs of the model are added to the stream.
+type OutputOrder string
+
+const (
+	// OutputOrderModel keeps the order in which the outputs are defined in the model.
+	OutputOrderModel OutputOrder = "model"
+	// OutputOrderResID sorts the outputs by group, version, kind, namespace and name.
+	OutputOrderResID OutputOrder = "resid"
+	// OutputOrderInstall sorts the outputs in the order Helm installs them (Namespaces, CRDs, RBAC, ...),
+	// then by resource ID.
+	OutputOrderInstall OutputOrder = "install"
+)
+
+// Validate returns an error if the output order is not one of the supported ones.
+// An empty output order is valid, and stands for OutputOrderModel.
+func (o OutputOrder) Validate() error {
+	switch o {
+	case "", OutputOrderModel, OutputOrderResID, OutputOrderInstall:
+		return nil
+	default:
+		return fmt.Errorf(`unsupported output order "%s", must be one of: %s, %s, %s`, o,
+			OutputOrderModel, OutputOrderResID, OutputOrderInstall)
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"errors"
+
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// OwnedByAnnotation is the annotation marking the generated resources with the name of the function config
+// that owns them.
+const OwnedByAnnotation = "config.cuestomize.io/owned-by"
+
+// Ownership configures the marking of the generated resources with the function config that owns them,
+// so that re-running the function replaces, or prunes, the resources it generated on a previous run,
+// instead of duplicating them.
+type Ownership struct {
+	// Enabled tells whether the generated resources must be marked, and the previously marked ones pruned.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// Validate returns an error if ownership is enabled for a function config without a name, as the name
+// identifies the owner of the resources.
+func (o *Ownership) Validate(configName string) error {
+	if o.Enabled && configName == "" {
+		return errors.New("the function config must have a name to own the generated resources")
+	}
+	return nil
+}
+
+// Owns tells whether the item is owned by the function config, i.e. whether it was generated by a previous
+// run of the function config with ownership enabled.
+func (i *KRMInput) Owns(item *kyaml.RNode) bool {
+	return i.Ownership != nil && i.Ownership.Enabled && item.GetAnnotations()[OwnedByAnnotation] == i.Name
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// placeholderRegexp matches the placeholders of a path template.
+var placeholderRegexp = regexp.MustCompile(`{{\s*([^}]*?)\s*}}`)
+
+// pathTemplatePlaceholders maps each placeholder of a path template to the value it is replaced with.
+var pathTemplatePlaceholders = map[string]func(id resid.ResId) string{
+	"apiVersion": func(id resid.ResId) string { return id.ApiVersion() },
+	"group":      func(id resid.ResId) string { return id.Group },
+	"version":    func(id resid.ResId) string { return id.Version },
+	"kind":       func(id resid.ResId) string { return id.Kind },
+	"namespace":  func(id resid.ResId) string { return id.Namespace },
+	"name":       func(id resid.ResId) string { return id.Name },
+}
+
+// PathTemplate is the template of the file path the generated resources are written to,
+// e.g. {{namespace}}/{{kind}}-{{name}}.yaml.
+type PathTemplate string
+
+// Validate returns an error if the path template refers to unknown placeholders.
+// An empty path template is valid, and leaves the generated resources in the default file.
+func (t PathTemplate) Validate() error {
+	for _, match := range placeholderRegexp.FindAllStringSubmatch(string(t), -1) {
+		if _, ok := pathTemplatePlaceholders[match[1]]; !ok {
+			known := make([]string, 0, len(pathTemplatePlaceholders))
+			for k := range pathTemplatePlaceholders {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return fmt.Errorf(`unknown placeholder "%s", must be one of: %v`, match[1], known)
+		}
+	}
+	return nil
+}
+
+// Render returns the file path of the given resource. Empty path segments, such as the namespace
+// of cluster-scoped resources, are dropped.
+func (t PathTemplate) Render(node *kyaml.RNode) (string, error) {
+	id := resid.FromRNode(node)
+	rendered := placeholderRegexp.ReplaceAllStringFunc(string(t), func(placeholder string) string {
+		name := placeholderRegexp.FindStringSubmatch(placeholder)[1]
+		if value, ok := pathTemplatePlaceholders[name]; ok {
+			return value(id)
+		}
+		return placeholder
+	})
+
+	rendered = strings.TrimPrefix(path.Clean("/"+rendered), "/")
+	if !filepath.IsLocal(rendered) {
+		return "", fmt.Errorf("path '%s' of %s is not a relative path", rendered, id)
+	}
+	return rendered, nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestPathTemplate(t *testing.T) {
+	deployment := kyaml.MustParse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+`)
+	clusterRole := kyaml.MustParse(`apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+`)
+
+	tests := []struct {
+		name           string
+		template       PathTemplate
+		node           *kyaml.RNode
+		expected       string
+		errorSubstring string
+	}{
+		{
+			name:     "namespaced resource",
+			template: "{{namespace}}/{{kind}}-{{name}}.yaml",
+			node:     deployment,
+			expected: "default/Deployment-app.yaml",
+		},
+		{
+			name:     "cluster-scoped resource drops the namespace",
+			template: "{{namespace}}/{{kind}}-{{name}}.yaml",
+			node:     clusterRole,
+			expected: "ClusterRole-reader.yaml",
+		},
+		{
+			name:     "group and version",
+			template: "{{ group }}/{{version}}/{{name}}.yaml",
+			node:     clusterRole,
+			expected: "rbac.authorization.k8s.io/v1/reader.yaml",
+		},
+		{
+			name:           "unknown placeholder",
+			template:       "{{cluster}}/{{name}}.yaml",
+			node:           deployment,
+			errorSubstring: `unknown placeholder "cluster"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.template.Validate()
+			if tt.errorSubstring != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+
+			rendered, err := tt.template.Render(tt.node)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, rendered)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+// Paths holds the CUE paths used to exchange data with the CUE model.
+// Empty paths are not configured, and fall back to their default value.
+type Paths struct {
+	// Input is the CUE path in which the input is filled.
+	Input string `yaml:"input,omitempty" json:"input,omitempty"`
+	// Includes is the CUE path in which the includes are filled.
+	Includes string `yaml:"includes,omitempty" json:"includes,omitempty"`
+	// CRDs is the CUE path in which the schemas of the CustomResourceDefinitions of the stream are filled.
+	CRDs string `yaml:"crds,omitempty" json:"crds,omitempty"`
+	// Outputs is the CUE path from which the generated resources are read.
+	Outputs string `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+	// Patches is the CUE path from which the patches are read.
+	Patches string `yaml:"patches,omitempty" json:"patches,omitempty"`
+	// Deletions is the CUE path from which the selectors of the items to delete are read.
+	Deletions string `yaml:"deletions,omitempty" json:"deletions,omitempty"`
+	// Results is the CUE path from which the function results (info, warning and error messages) are read.
+	Results string `yaml:"results,omitempty" json:"results,omitempty"`
+	// Policies is the CUE path from which the policies (definitions the matching stream items must satisfy) are read.
+	Policies string `yaml:"policies,omitempty" json:"policies,omitempty"`
+	// APIVersion is the CUE path in which the apiVersion of the function config is filled.
+	APIVersion string `yaml:"apiVersion,omitempty" json:"apiVersion,omitempty"`
+	// Kind is the CUE path in which the kind of the function config is filled.
+	Kind string `yaml:"kind,omitempty" json:"kind,omitempty"`
+	// Metadata is the CUE path in which the metadata of the function config is filled.
+	Metadata string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+}
+
+// PathEntry is a named CUE path of Paths.
+type PathEntry struct {
+	// Name is the name of the path in the paths block of the function config.
+	Name string
+	// Annotation is the annotation of the function config that configures the path.
+	Annotation string
+	// Path is the CUE path.
+	Path string
+}
+
+// Entries returns the named CUE paths, in declaration order.
+func (p Paths) Entries() []PathEntry {
+	fields := p.fields()
+	entries := make([]PathEntry, 0, len(fields))
+	for _, f := range fields {
+		entries = append(entries, PathEntry{Name: f.name, Annotation: pathAnnotation(f.annotationName), Path: *f.value})
+	}
+	return entries
+}
+
+// Merge returns the paths, with the non-empty paths of override taking precedence.
+func (p Paths) Merge(override Paths) Paths {
+	merged := p
+	mergedFields, overrideFields := merged.fields(), override.fields()
+	for i := range mergedFields {
+		if *overrideFields[i].value != "" {
+			*mergedFields[i].value = *overrideFields[i].value
+		}
+	}
+	return merged
+}
+
+// ConfiguredPaths returns the CUE paths configured in the function config, either through annotations
+// (e.g. config.cuestomize.io/input-path) or through the paths block, which takes precedence.
+func (i *KRMInput) ConfiguredPaths() Paths {
+	var fromAnnotations Paths
+	for _, f := range fromAnnotations.fields() {
+		*f.value = i.Annotations[pathAnnotation(f.annotationName)]
+	}
+	if i.Paths == nil {
+		return fromAnnotations
+	}
+	return fromAnnotations.Merge(*i.Paths)
+}
+
+// pathField is a reference to a field of Paths.
+type pathField struct {
+	name           string
+	annotationName string
+	value          *string
+}
+
+// fields returns references to the fields of the paths, in declaration order.
+func (p *Paths) fields() []pathField {
+	return []pathField{
+		{name: "input", annotationName: "input", value: &p.Input},
+		{name: "includes", annotationName: "includes", value: &p.Includes},
+		{name: "crds", annotationName: "crds", value: &p.CRDs},
+		{name: "outputs", annotationName: "outputs", value: &p.Outputs},
+		{name: "patches", annotationName: "patches", value: &p.Patches},
+		{name: "deletions", annotationName: "deletions", value: &p.Deletions},
+		{name: "results", annotationName: "results", value: &p.Results},
+		{name: "policies", annotationName: "policies", value: &p.Policies},
+		{name: "apiVersion", annotationName: "api-version", value: &p.APIVersion},
+		{name: "kind", annotationName: "kind", value: &p.Kind},
+		{name: "metadata", annotationName: "metadata", value: &p.Metadata},
+	}
+}
+
+// pathAnnotation returns the annotation key configuring the path with the given annotation name.
+func pathAnnotation(annotationName string) string {
+	return "config.cuestomize.io/" + annotationName + "-path"
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKRMInput_ConfiguredPaths(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		paths       *Paths
+		expected    Paths
+	}{
+		{
+			name:     "nothing configured",
+			expected: Paths{},
+		},
+		{
+			name: "annotations only",
+			annotations: map[string]string{
+				"config.cuestomize.io/input-path":       "values",
+				"config.cuestomize.io/api-version-path": "spec.apiVersion",
+			},
+			expected: Paths{Input: "values", APIVersion: "spec.apiVersion"},
+		},
+		{
+			name: "paths block takes precedence over annotations",
+			annotations: map[string]string{
+				"config.cuestomize.io/input-path":   "values",
+				"config.cuestomize.io/outputs-path": "objects",
+			},
+			paths:    &Paths{Input: "spec.values", Includes: "resources"},
+			expected: Paths{Input: "spec.values", Includes: "resources", Outputs: "objects"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			krm := &KRMInput{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+				Paths:      tt.paths,
+			}
+
+			require.Equal(t, tt.expected, krm.ConfiguredPaths())
+		})
+	}
+}
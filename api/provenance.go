@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// ProvenanceConfigName is the provenance information holding the name of the function config.
+	ProvenanceConfigName = "configName"
+	// ProvenanceSource is the provenance information holding the local path, or registry and repository, of the module.
+	ProvenanceSource = "source"
+	// ProvenanceTag is the provenance information holding the tag the module was fetched with.
+	ProvenanceTag = "tag"
+	// ProvenanceDigest is the provenance information holding the resolved digest of the module.
+	ProvenanceDigest = "digest"
+	// ProvenancePath is the provenance information holding the CUE path that produced the resource.
+	ProvenancePath = "path"
+)
+
+// DefaultProvenanceAnnotations maps each piece of provenance information to the annotation key it is stamped with by default.
+var DefaultProvenanceAnnotations = map[string]string{
+	ProvenanceConfigName: "config.cuestomize.io/config-name",
+	ProvenanceSource:     "config.cuestomize.io/module-source",
+	ProvenanceTag:        "config.cuestomize.io/module-tag",
+	ProvenanceDigest:     "config.cuestomize.io/module-digest",
+	ProvenancePath:       "config.cuestomize.io/cue-path",
+}
+
+// Provenance configures the annotations stamped on the generated resources to record where they come from.
+type Provenance struct {
+	// Enabled tells whether the provenance annotations must be stamped on the generated resources.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Annotations overrides the annotation key of some pieces of provenance information (configName, source,
+	// tag, digest, path). An empty annotation key suppresses the corresponding annotation.
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// Validate returns an error if the provenance configuration refers to unknown pieces of information.
+func (p *Provenance) Validate() error {
+	for info := range p.Annotations {
+		if _, ok := DefaultProvenanceAnnotations[info]; !ok {
+			known := make([]string, 0, len(DefaultProvenanceAnnotations))
+			for k := range DefaultProvenanceAnnotations {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return fmt.Errorf(`unknown provenance information "%s", must be one of: %v`, info, known)
+		}
+	}
+	return nil
+}
+
+// AnnotationKey returns the annotation key the given piece of provenance information is stamped with,
+// or an empty string if the annotation is suppressed.
+func (p *Provenance) AnnotationKey(info string) string {
+	if key, ok := p.Annotations[info]; ok {
+		return key
+	}
+	return DefaultProvenanceAnnotations[info]
+}
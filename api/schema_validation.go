@@ -0,0 +1,37 @@
+package api
+
+import "fmt"
+
+// UnknownFieldsPolicy defines how fields not declared in the schema of a generated resource are reported.
+type UnknownFieldsPolicy string
+
+const (
+	// UnknownFieldsError reports unknown fields as violations, making the function fail.
+	UnknownFieldsError UnknownFieldsPolicy = "error"
+	// UnknownFieldsWarn reports the fields not declared in the bundled Kubernetes schemas as warning results,
+	// e.g. to generate fields added by Kubernetes versions newer than the bundled schemas.
+	// Fields not declared in the schema of a CustomResourceDefinition are still violations.
+	UnknownFieldsWarn UnknownFieldsPolicy = "warn"
+)
+
+// DefaultUnknownFieldsPolicy is the unknown fields policy used when none is configured.
+const DefaultUnknownFieldsPolicy = UnknownFieldsError
+
+// SchemaValidation configures the validation of the generated resources against the Kubernetes OpenAPI schemas.
+type SchemaValidation struct {
+	// Enabled tells whether the generated resources must be validated.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// UnknownFields defines how fields not declared in the bundled schemas are reported (default: error).
+	UnknownFields UnknownFieldsPolicy `yaml:"unknownFields,omitempty" json:"unknownFields,omitempty"`
+}
+
+// Validate returns an error if the unknown fields policy is not one of the supported ones.
+func (s *SchemaValidation) Validate() error {
+	switch s.UnknownFields {
+	case "", UnknownFieldsError, UnknownFieldsWarn:
+		return nil
+	default:
+		return fmt.Errorf(`unsupported unknownFields policy "%s", must be one of: %s, %s`,
+			s.UnknownFields, UnknownFieldsError, UnknownFieldsWarn)
+	}
+}
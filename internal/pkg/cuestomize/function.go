@@ -7,7 +7,9 @@ import (
 	"github.com/Workday/cuestomize/pkg/cuerrors"
 	"github.com/Workday/cuestomize/pkg/cuestomize"
 	"github.com/Workday/cuestomize/pkg/cuestomize/model"
+	"github.com/Workday/cuestomize/pkg/report"
 
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
 	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
@@ -38,6 +40,27 @@ func newCuestomizeFunctionWithPath(ctx context.Context, config *api.KRMInput, re
 			provider = model.NewLocalPathProvider(*resourcesPath)
 		}
 
-		return cuestomize.Cuestomize(ctx, items, config, cuestomize.WithModelProvider(provider))
+		items, results, err := cuestomize.Cuestomize(ctx, items, config, cuestomize.WithModelProvider(provider))
+		if err != nil {
+			return nil, err
+		}
+		if err := writeReports(ctx, config, items, results); err != nil {
+			return nil, err
+		}
+		// the KRM framework takes the results from the error of the filter: it adds them to the ResourceList,
+		// and the processor fails the run only if one of them has error severity
+		if len(results) > 0 {
+			return items, results
+		}
+		return items, nil
+	}
+}
+
+// writeReports writes the results of a validator run in the reports configured for the function config.
+// Runs failing before validating have no report.
+func writeReports(ctx context.Context, config *api.KRMInput, items []*kyaml.RNode, results framework.Results) error {
+	if !cuestomize.ShouldActAsValidator(config) {
+		return nil
 	}
+	return report.Write(ctx, config, results, items)
 }
@@ -63,6 +63,54 @@ func TestKRMFunction(t *testing.T) {
 			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/configmap-wrong-apiversion",
 			ShouldFail:            true,
 		},
+		{
+			Name:                  "configmap-model with configmap-inputfrom-ok should succeed",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/configmap-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/configmap-inputfrom-ok",
+			ShouldFail:            false,
+			Expected: []resid.ResId{
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "cuestomize.dev", Version: "v1alpha1", Kind: "Cuestomization"}, "example-cuestomization", ""),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}, "example-deployment", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "Service"}, "example-service", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "ConfigMap"}, "example-values", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "ConfigMap"}, "example-configmap", "default"),
+			},
+		},
+		{
+			Name:                  "configmap-model with configmap-inputfrom-conflict should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/configmap-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/configmap-inputfrom-conflict",
+			ShouldFail:            true,
+		},
+		{
+			Name:                  "configmap-model with configmap-inputfiles-ok should succeed",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/configmap-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/configmap-inputfiles-ok",
+			ShouldFail:            false,
+			Expected: []resid.ResId{
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "cuestomize.dev", Version: "v1alpha1", Kind: "Cuestomization"}, "example-cuestomization", ""),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}, "example-deployment", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "Service"}, "example-service", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "ConfigMap"}, "example-configmap", "default"),
+			},
+		},
+		{
+			Name:                  "configmap-model with configmap-inputfiles-conflict should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/configmap-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/configmap-inputfiles-conflict",
+			ShouldFail:            true,
+		},
+		{
+			Name:                  "configmap-model with configmap-consume-ok should succeed",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/configmap-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/configmap-consume-ok",
+			ShouldFail:            false,
+			Expected: []resid.ResId{
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "cuestomize.dev", Version: "v1alpha1", Kind: "Cuestomization"}, "example-cuestomization", ""),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "Service"}, "example-service", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "ConfigMap"}, "example-configmap", "default"),
+			},
+		},
 		// deployment-model tests
 		{
 			Name:                  "deployment-model with deployment-ok should succeed",
@@ -76,6 +124,24 @@ func TestKRMFunction(t *testing.T) {
 				resid.NewResIdWithNamespace(resid.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}, "nginx-deployment", "nginx"),
 			},
 		},
+		{
+			Name:                  "deployment-model with deployment-schema-ok should succeed",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/deployment-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/deployment-schema-ok",
+			ShouldFail:            false,
+			Expected: []resid.ResId{
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "cuestomize.dev", Version: "v1alpha1", Kind: "NginxDeployment"}, "nginx-deployment", ""),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "Service"}, "example-service", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}, "example-deployment", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}, "nginx-deployment", "nginx"),
+			},
+		},
+		{
+			Name:                  "deployment-model with deployment-schema-invalid should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/deployment-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/deployment-schema-invalid",
+			ShouldFail:            true,
+		},
 		{
 			Name:                  "deployment-model with deployment-unexpected-includes should fail",
 			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/deployment-model",
@@ -94,6 +160,96 @@ func TestKRMFunction(t *testing.T) {
 			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/deployment-wrong-kind",
 			ShouldFail:            true,
 		},
+		// values-objects-model tests
+		{
+			Name:                  "values-objects-model with values-objects-ok should succeed",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/values-objects-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/values-objects-ok",
+			ShouldFail:            false,
+			Expected: []resid.ResId{
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "cuestomize.dev", Version: "v1alpha1", Kind: "Cuestomization"}, "example-cuestomization", ""),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "apps", Version: "v1", Kind: "Deployment"}, "example-deployment", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "Service"}, "example-service", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "ConfigMap"}, "example-configmap", "default"),
+			},
+		},
+		{
+			Name:                  "values-objects-model with values-objects-missing-path should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/values-objects-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/values-objects-missing-path",
+			ShouldFail:            true,
+		},
+		{
+			Name:                  "values-objects-model with configmap-ok should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/values-objects-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/configmap-ok",
+			ShouldFail:            true,
+		},
+		// tags-model tests
+		{
+			Name:                  "tags-model with tags-ok should succeed",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/tags-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/tags-ok",
+			ShouldFail:            false,
+			Expected: []resid.ResId{
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "cuestomize.dev", Version: "v1alpha1", Kind: "Cuestomization"}, "example-cuestomization", ""),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "Service"}, "example-service", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "ConfigMap"}, "prod-config", "default"),
+			},
+		},
+		{
+			Name:                  "tags-model with tags-missing-tag should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/tags-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/tags-missing-tag",
+			ShouldFail:            true,
+		},
+		{
+			Name:                  "tags-model with tags-unknown-tag should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/tags-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/tags-unknown-tag",
+			ShouldFail:            true,
+		},
+		// multi-package-model tests
+		{
+			Name:                  "multi-package-model with package-dir-ok should succeed",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/multi-package-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/package-dir-ok",
+			ShouldFail:            false,
+			Expected: []resid.ResId{
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "cuestomize.dev", Version: "v1alpha1", Kind: "Cuestomization"}, "example-cuestomization", ""),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "Service"}, "example-service", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "ConfigMap"}, "web-config", "default"),
+			},
+		},
+		{
+			Name:                  "multi-package-model with package-select-ok should succeed",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/multi-package-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/package-select-ok",
+			ShouldFail:            false,
+			Expected: []resid.ResId{
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "cuestomize.dev", Version: "v1alpha1", Kind: "Cuestomization"}, "example-cuestomization", ""),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "Service"}, "example-service", "example-namespace"),
+				resid.NewResIdWithNamespace(resid.Gvk{Group: "", Version: "v1", Kind: "ConfigMap"}, "beta-config", "default"),
+			},
+		},
+		{
+			Name:                  "multi-package-model with package-not-found should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/multi-package-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/package-not-found",
+			ShouldFail:            true,
+		},
+		{
+			Name:                  "multi-package-model with package-ambiguous should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/multi-package-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/package-ambiguous",
+			ShouldFail:            true,
+		},
+		{
+			Name:                  "multi-package-model with package-dir-outside should fail",
+			TestdataCUEModelPath:  "../../../testdata/function/cue-modules/multi-package-model",
+			TestdataKustomizePath: "../../../testdata/function/kustomize-inputs/package-dir-outside",
+			ShouldFail:            true,
+		},
 		// fuzzy-model tests
 		{
 			Name:                  "configmap-model with deployment-ok should fail",
@@ -35,11 +35,19 @@ func NewSimpleProcessor(config interface{}, filter kio.Filter, strict bool) Simp
 // Process makes SimpleProcessor implement the ResourceListProcessor interface.
 // It loads the ResourceList.functionConfig into the provided Config type, applying
 // defaulting and validation if supported by Config. It then executes the processor's filter.
+// Results returned by the filter are stored in the ResourceList, and fail the processing
+// if any of them has error severity.
 func (p SimpleProcessor) Process(rl *fw.ResourceList) error {
 	if err := LoadFunctionConfig(rl.FunctionConfig, p.Config, p.Strict); err != nil {
 		return errors.WrapPrefixf(err, "loading function config")
 	}
-	return errors.WrapPrefixf(rl.Filter(p.Filter), "processing filter")
+	if err := rl.Filter(p.Filter); err != nil {
+		return errors.WrapPrefixf(err, "processing filter")
+	}
+	if rl.Results.ExitCode() != 0 {
+		return rl.Results
+	}
+	return nil
 }
 
 // LoadFunctionConfig reads a configuration resource from YAML into the provided data structure
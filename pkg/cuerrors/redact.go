@@ -0,0 +1,144 @@
+package cuerrors
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactedPlaceholder is the text that replaces sensitive values in redacted errors.
+const RedactedPlaceholder = "<redacted>"
+
+// MinSensitiveLength is the minimum length of the sensitive values that are redacted wherever they appear:
+// shorter values (e.g. "1" or "no") are only redacted in their quoted form (e.g. "no", quotes included), as
+// redacting them anywhere would mangle unrelated parts of the messages.
+const MinSensitiveLength = 4
+
+// RedactingDetailer is a Detailer that removes sensitive values from the errors formatted by another Detailer.
+type RedactingDetailer struct {
+	Detailer Detailer
+	// values are the sensitive values, longest first.
+	values []sensitiveValue
+}
+
+// sensitiveValue is a text to redact, which is quoted if it is the quoted form of a short sensitive value.
+type sensitiveValue struct {
+	text   string
+	quoted bool
+}
+
+// NewRedactingDetailer creates a new RedactingDetailer that formats errors with the given Detailer, and then
+// replaces every occurrence of the given sensitive values with RedactedPlaceholder.
+// Values are also redacted in their quoted form, as they are printed in CUE errors.
+// Only whole tokens are replaced, so that a value is not redacted from within a longer word (e.g. "true" from
+// "trueish"), and values shorter than MinSensitiveLength are only redacted in their quoted form.
+func NewRedactingDetailer(detailer Detailer, sensitive []string) RedactingDetailer {
+	var values []sensitiveValue
+	for _, s := range sensitive {
+		if s == "" {
+			continue
+		}
+		quoted := strconv.Quote(s)
+		if len(s) < MinSensitiveLength {
+			values = append(values, sensitiveValue{text: quoted, quoted: true})
+			continue
+		}
+		values = append(values, sensitiveValue{text: s})
+		if escaped := quoted[1 : len(quoted)-1]; escaped != s {
+			values = append(values, sensitiveValue{text: escaped})
+		}
+	}
+	// replace longer values first, so that values containing other values are fully redacted
+	sort.SliceStable(values, func(i, j int) bool { return len(values[i].text) > len(values[j].text) })
+
+	return RedactingDetailer{
+		Detailer: detailer,
+		values:   values,
+	}
+}
+
+// ErrorWithDetails formats an error message with the wrapped Detailer, and redacts the sensitive values from it.
+func (d RedactingDetailer) ErrorWithDetails(err error, format string, args ...any) error {
+	detailed := d.Detailer.ErrorWithDetails(err, format, args...)
+	return redactedError{
+		msg: d.Redact(detailed.Error()),
+		err: detailed,
+	}
+}
+
+// Redact replaces every occurrence of the sensitive values in s with RedactedPlaceholder.
+func (d RedactingDetailer) Redact(s string) string {
+	if len(d.values) == 0 {
+		return s
+	}
+
+	var redacted strings.Builder
+	for i := 0; i < len(s); {
+		if value, ok := d.tokenAt(s, i); ok {
+			if value.quoted {
+				redacted.WriteString(strconv.Quote(RedactedPlaceholder))
+			} else {
+				redacted.WriteString(RedactedPlaceholder)
+			}
+			i += len(value.text)
+			continue
+		}
+		redacted.WriteByte(s[i])
+		i++
+	}
+	return redacted.String()
+}
+
+// tokenAt returns the longest sensitive value found in s at index i as a whole token, i.e. not preceded
+// nor followed by a word character it would be part of, and false if there is none.
+func (d RedactingDetailer) tokenAt(s string, i int) (sensitiveValue, bool) {
+	for _, value := range d.values {
+		if !strings.HasPrefix(s[i:], value.text) {
+			continue
+		}
+		end := i + len(value.text)
+		if i > 0 && isWordByte(s[i-1]) && isWordByte(value.text[0]) {
+			continue
+		}
+		if end < len(s) && isWordByte(s[end]) && isWordByte(value.text[len(value.text)-1]) {
+			continue
+		}
+		return value, true
+	}
+	return sensitiveValue{}, false
+}
+
+// isWordByte tells whether b is a letter, a digit or an underscore, or part of a multi-byte character.
+func isWordByte(b byte) bool {
+	return b == '_' || b >= utf8.RuneSelf || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// Redactor is implemented by Detailers that remove sensitive values from the messages they format.
+type Redactor interface {
+	// Redact removes the sensitive values from s.
+	Redact(s string) string
+}
+
+// Redact removes the sensitive values from s if the Detailer is a Redactor, and returns s unchanged otherwise.
+// It allows to redact messages that are not formatted as errors, e.g. function results.
+func Redact(detailer Detailer, s string) string {
+	if redactor, ok := detailer.(Redactor); ok {
+		return redactor.Redact(s)
+	}
+	return s
+}
+
+// redactedError is an error whose message has been redacted, that still allows to get the source error.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e redactedError) Error() string {
+	return e.msg
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
@@ -6,15 +6,31 @@ import (
 	"cuelang.org/go/cue/build"
 	"github.com/Workday/cuestomize/api"
 	"github.com/Workday/cuestomize/pkg/cuerrors"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
 )
 
 const (
 	// ValidatorAnnotationKey is the annotation key that marks a CUE function as a validator.
 	ValidatorAnnotationKey = "config.cuestomize.io/validator"
-	// ValidatorAnnotationValue is the value of the annotation that marks a CUE function as a validator.
+	// ValidatorAnnotationValue is the value of the annotation that marks a CUE function as a validator,
+	// enforcing the constraints of the model.
 	ValidatorAnnotationValue = "true"
 )
 
+// ValidatorMode is how a validator reports the violations of the constraints of the CUE model.
+type ValidatorMode string
+
+const (
+	// ValidatorModeEnforce reports the violations with their severity (error, unless set otherwise by the model),
+	// failing the run on errors.
+	ValidatorModeEnforce ValidatorMode = "enforce"
+	// ValidatorModeWarn reports the violations as warnings, without failing the run.
+	ValidatorModeWarn ValidatorMode = "warn"
+	// ValidatorModeDryRun only logs the violations, without reporting them as results.
+	ValidatorModeDryRun ValidatorMode = "dry-run"
+)
+
 // CheckInstances checks if any of the instances have an error and returns an error if so.
 func CheckInstances(ctx context.Context, instances []*build.Instance) error {
 	detailer := cuerrors.FromContextOrEmpty(ctx)
@@ -29,6 +45,46 @@ func CheckInstances(ctx context.Context, instances []*build.Instance) error {
 
 // ShouldActAsValidator checks if the KRMInput configuration has the validator annotation set.
 func ShouldActAsValidator(config *api.KRMInput) bool {
-	return config.Annotations != nil &&
-		config.Annotations[ValidatorAnnotationKey] == ValidatorAnnotationValue
+	return GetValidatorMode(config) != ""
+}
+
+// GetValidatorMode returns the validator mode set by the validator annotation of the KRMInput configuration,
+// or an empty mode if the function is not a validator. "true" is the same as "enforce", and any other value
+// leaves the function a generator, as it did before the validator modes were introduced.
+func GetValidatorMode(config *api.KRMInput) ValidatorMode {
+	switch value := config.Annotations[ValidatorAnnotationKey]; value {
+	case ValidatorAnnotationValue:
+		return ValidatorModeEnforce
+	case string(ValidatorModeEnforce), string(ValidatorModeWarn), string(ValidatorModeDryRun):
+		return ValidatorMode(value)
+	default:
+		return ""
+	}
+}
+
+// mutates tells whether the policies of the model mutate the items in the validator mode.
+// In warn and dry-run modes the items pass through unchanged, and the policies only report their violations.
+func (m ValidatorMode) mutates() bool {
+	return m != ValidatorModeWarn && m != ValidatorModeDryRun
+}
+
+// ReportViolations returns the results of the violations as the validator mode reports them, and logs the
+// violations that are not errors, as they do not fail the run. An empty mode enforces the violations.
+func ReportViolations(ctx context.Context, mode ValidatorMode, results framework.Results) framework.Results {
+	log := logr.FromContextOrDiscard(ctx)
+
+	reported := make(framework.Results, 0, len(results))
+	for _, result := range results {
+		if mode == ValidatorModeWarn && result.Severity == framework.Error {
+			result.Severity = framework.Warning
+		}
+		if mode == ValidatorModeDryRun || result.Severity != framework.Error {
+			log.V(-1).Info("constraint violated", "violation", result.String())
+		}
+		if mode == ValidatorModeDryRun {
+			continue
+		}
+		reported = append(reported, result)
+	}
+	return reported
 }
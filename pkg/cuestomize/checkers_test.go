@@ -0,0 +1,110 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+)
+
+func TestGetValidatorMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    ValidatorMode
+	}{
+		{
+			name:     "not a validator",
+			expected: "",
+		},
+		{
+			name:        "true enforces",
+			annotations: map[string]string{ValidatorAnnotationKey: "true"},
+			expected:    ValidatorModeEnforce,
+		},
+		{
+			name:        "enforce",
+			annotations: map[string]string{ValidatorAnnotationKey: "enforce"},
+			expected:    ValidatorModeEnforce,
+		},
+		{
+			name:        "warn",
+			annotations: map[string]string{ValidatorAnnotationKey: "warn"},
+			expected:    ValidatorModeWarn,
+		},
+		{
+			name:        "dry-run",
+			annotations: map[string]string{ValidatorAnnotationKey: "dry-run"},
+			expected:    ValidatorModeDryRun,
+		},
+		{
+			name:        "false is not a validator",
+			annotations: map[string]string{ValidatorAnnotationKey: "false"},
+			expected:    "",
+		},
+		{
+			name:        "other spellings of true are not a validator",
+			annotations: map[string]string{ValidatorAnnotationKey: "True"},
+			expected:    "",
+		},
+		{
+			name:        "yes is not a validator",
+			annotations: map[string]string{ValidatorAnnotationKey: "yes"},
+			expected:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			config := &api.KRMInput{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+
+			require.Equal(t, tt.expected, GetValidatorMode(config))
+			require.Equal(t, tt.expected != "", ShouldActAsValidator(config))
+		})
+	}
+}
+
+func TestReportViolations(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     ValidatorMode
+		expected []framework.Severity
+	}{
+		{
+			name:     "enforce keeps the severities",
+			mode:     ValidatorModeEnforce,
+			expected: []framework.Severity{framework.Error, framework.Warning},
+		},
+		{
+			name:     "warn downgrades errors",
+			mode:     ValidatorModeWarn,
+			expected: []framework.Severity{framework.Warning, framework.Warning},
+		},
+		{
+			name:     "dry-run reports nothing",
+			mode:     ValidatorModeDryRun,
+			expected: []framework.Severity{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			results := framework.Results{
+				{Message: "invalid value 1 (out of bound >=2)", Severity: framework.Error},
+				{Message: "field is required but not present", Severity: framework.Warning},
+			}
+
+			reported := ReportViolations(t.Context(), tt.mode, results)
+
+			severities := make([]framework.Severity, 0, len(reported))
+			for _, result := range reported {
+				severities = append(severities, result.Severity)
+			}
+			require.Equal(t, tt.expected, severities)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package cuestomize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Workday/cuestomize/api"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// CheckConflicts checks that no two outputs share the same resource ID (apiVersion, kind, namespace and name)
+// and, if the merge policy is error, that no output shares its resource ID with an item of the stream.
+// All the conflicts are reported at once, along with the CUE path of each conflicting output.
+func CheckConflicts(outputs []Output, items []*kyaml.RNode, policy api.MergePolicy) error {
+	var conflicts []string
+
+	// IDs are keyed by their canonical form, but reported as generated by the first output
+	var ids []resid.ResId
+	pathsByID := make(map[resid.ResId][]string)
+	for _, output := range outputs {
+		id := resid.FromRNode(output.Node)
+		key := canonicalID(id)
+		if _, ok := pathsByID[key]; !ok {
+			ids = append(ids, id)
+		}
+		pathsByID[key] = append(pathsByID[key], fmt.Sprintf("'%v'", output.Path))
+	}
+
+	for _, id := range ids {
+		if paths := pathsByID[canonicalID(id)]; len(paths) > 1 {
+			conflicts = append(conflicts, fmt.Sprintf("%s is generated by multiple outputs: %s", id, strings.Join(paths, ", ")))
+		}
+	}
+
+	if policy.OrDefault() == api.MergePolicyError {
+		for _, id := range ids {
+			if indexOf(items, id) >= 0 {
+				conflicts = append(conflicts, fmt.Sprintf("%s generated by %s is already in the stream (merge policy is %s)",
+					id, strings.Join(pathsByID[canonicalID(id)], ", "), api.MergePolicyError))
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("found conflicting outputs:\n- %s", strings.Join(conflicts, "\n- "))
+	}
+	return nil
+}
+
+// canonicalID returns the resource ID with its namespace set to the effective one, so that
+// IDs referring to the same resource are equal (e.g. the empty and the default namespace).
+func canonicalID(id resid.ResId) resid.ResId {
+	id.Namespace = id.EffectiveNamespace()
+	return id
+}
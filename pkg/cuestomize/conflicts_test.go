@@ -0,0 +1,75 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestCheckConflicts(t *testing.T) {
+	tests := []struct {
+		name           string
+		model          string
+		policy         api.MergePolicy
+		errorSubstring string
+	}{
+		{
+			name: "no conflicts",
+			model: `outputs: [
+	{apiVersion: "v1", kind: "ConfigMap", metadata: {name: "a", namespace: "default"}},
+	{apiVersion: "v1", kind: "ConfigMap", metadata: {name: "b", namespace: "default"}},
+]`,
+		},
+		{
+			name: "duplicated list outputs",
+			model: `outputs: [
+	{apiVersion: "v1", kind: "ConfigMap", metadata: {name: "a", namespace: "default"}},
+	{apiVersion: "v1", kind: "ConfigMap", metadata: {name: "b", namespace: "default"}},
+	{apiVersion: "v1", kind: "ConfigMap", metadata: {name: "a"}},
+]`,
+			errorSubstring: "is generated by multiple outputs: 'outputs[0]', 'outputs[2]'",
+		},
+		{
+			name: "duplicated struct outputs",
+			model: `outputs: {
+	first: {apiVersion: "v1", kind: "ConfigMap", metadata: {name: "a", namespace: "default"}}
+	second: {apiVersion: "v1", kind: "ConfigMap", metadata: {name: "a", namespace: "default"}}
+}`,
+			errorSubstring: "is generated by multiple outputs: 'outputs.first', 'outputs.second'",
+		},
+		{
+			name:           "output already in the stream",
+			model:          `outputs: cm: {apiVersion: "v1", kind: "ConfigMap", metadata: {name: "existing", namespace: "default"}}`,
+			errorSubstring: "generated by 'outputs.cm' is already in the stream (merge policy is error)",
+		},
+		{
+			name:   "output already in the stream with replace policy",
+			model:  `outputs: cm: {apiVersion: "v1", kind: "ConfigMap", metadata: {name: "existing", namespace: "default"}}`,
+			policy: api.MergePolicyReplace,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			unified := cuecontext.New().CompileString(tt.model)
+			require.NoError(t, unified.Err())
+			items := []*kyaml.RNode{kyaml.MustParse("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: existing\n")}
+
+			outputs, err := CollectOutputs(t.Context(), unified, OutputsPath)
+			require.NoError(t, err)
+
+			err = CheckConflicts(outputs, items, tt.policy)
+
+			if tt.errorSubstring == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.errorSubstring)
+		})
+	}
+}
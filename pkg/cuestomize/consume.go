@@ -0,0 +1,50 @@
+package cuestomize
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/go-logr/logr"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// RemoveConsumedIncludes returns the items that do not match any of the include selectors marked as consume.
+func RemoveConsumedIncludes(ctx context.Context, config *api.KRMInput, items []*kyaml.RNode) ([]*kyaml.RNode, error) {
+	log := logr.FromContextOrDiscard(ctx)
+
+	kept := make([]*kyaml.RNode, 0, len(items))
+	for _, item := range items {
+		consumed, err := isConsumed(config, item)
+		if err != nil {
+			return nil, err
+		}
+		if consumed {
+			log.V(4).Info("removing consumed include from output resources",
+				"kind", item.GetKind(), "apiVersion", item.GetApiVersion(), "namespace", item.GetNamespace(), "name", item.GetName())
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept, nil
+}
+
+// isConsumed checks if the item matches any of the include selectors marked as consume,
+// and is not exempted from the function config.
+func isConsumed(config *api.KRMInput, item *kyaml.RNode) (bool, error) {
+	for _, sel := range config.Includes {
+		if !sel.Consume {
+			continue
+		}
+		matches, err := api.ItemMatchReference(item, &sel.Selector)
+		if err != nil {
+			return false, fmt.Errorf("failed to match item against selector [%v]: %w", sel.String(), err)
+		}
+		if matches {
+			// exempted items are not included, so they are not consumed either
+			exempted, err := config.IsExempted(item)
+			return !exempted, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,90 @@
+package cuestomize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/encoding/jsonschema"
+	"github.com/Workday/cuestomize/pkg/cuerrors"
+	"github.com/go-logr/logr"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// CRDSchemas converts the OpenAPI v3 schemas of the versions of the (apiextensions.k8s.io/v1)
+// CustomResourceDefinitions among the items into CUE values, keyed by "<group>/<version>/<kind>".
+// Besides the schema, each value constrains the apiVersion and kind of the resource, and its metadata.
+func CRDSchemas(ctx context.Context, cueCtx *cue.Context, items []*kyaml.RNode) (map[string]cue.Value, error) {
+	log := logr.FromContextOrDiscard(ctx)
+	detailer := cuerrors.FromContextOrEmpty(ctx)
+
+	schemas := make(map[string]cue.Value)
+	for _, item := range items {
+		if !isCRD(item) {
+			continue
+		}
+		crd, err := withDefaultSingularName(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to default the singular name of CustomResourceDefinition '%s': %w", item.GetName(), err)
+		}
+		itemMap, err := crd.Map()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert CustomResourceDefinition '%s' to map: %w", item.GetName(), err)
+		}
+		crds, err := jsonschema.ExtractCRDs(cueCtx.Encode(itemMap), nil)
+		if err != nil {
+			return nil, detailer.ErrorWithDetails(err, "failed to extract the schemas of CustomResourceDefinition '%s'", item.GetName())
+		}
+
+		for _, crd := range crds {
+			for version, file := range crd.Versions {
+				key := crd.Data.Spec.Group + "/" + version + "/" + crd.Data.Spec.Names.Kind
+				schema := cueCtx.BuildFile(file)
+				if schema.Err() != nil {
+					return nil, detailer.ErrorWithDetails(schema.Err(), "failed to build the schema of '%s'", key)
+				}
+				log.V(4).Info("converted CustomResourceDefinition schema", "name", item.GetName(), "schema", key)
+				schemas[key] = schema
+			}
+		}
+	}
+	return schemas, nil
+}
+
+// withDefaultSingularName returns a copy of the CustomResourceDefinition, with its singular name defaulted to
+// its lowercased kind (as the API server does) if it has none.
+func withDefaultSingularName(crd *kyaml.RNode) (*kyaml.RNode, error) {
+	crd = crd.Copy()
+	if singular, _ := crd.GetString("spec.names.singular"); singular != "" {
+		return crd, nil
+	}
+	kind, err := crd.GetString("spec.names.kind")
+	if err != nil {
+		return nil, err
+	}
+	err = crd.PipeE(
+		kyaml.LookupCreate(kyaml.MappingNode, "spec", "names"),
+		kyaml.SetField("singular", kyaml.NewStringRNode(strings.ToLower(kind))),
+	)
+	return crd, err
+}
+
+// FillCRDs fills the CUE schema with the schemas of the CustomResourceDefinitions among the items, at the given
+// CRDs path, keyed by "<group>/<version>/<kind>".
+// The schemas are only filled if the CUE model declares the path (e.g. #crds: [string]: _).
+func FillCRDs(ctx context.Context, schema cue.Value, items []*kyaml.RNode, crdsPath string) (cue.Value, error) {
+	path := cue.ParsePath(crdsPath)
+	if !schema.LookupPath(path).Exists() {
+		return schema, nil
+	}
+
+	schemas, err := CRDSchemas(ctx, schema.Context(), items)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	for key, crdSchema := range schemas {
+		schema = schema.FillPath(cue.MakePath(append(path.Selectors(), cue.Str(key))...), crdSchema)
+	}
+	return schema, nil
+}
@@ -0,0 +1,91 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestFillCRDs(t *testing.T) {
+	items := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  scope: Namespaced
+  names:
+    kind: Widget
+    plural: widgets
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [size]
+            properties:
+              size:
+                type: integer
+                minimum: 1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`
+
+	tests := []struct {
+		name       string
+		model      string
+		violations []Violation
+	}{
+		{
+			name: "schemas are filled when declared",
+			model: `
+#crds: [string]: _
+widget: #crds["example.com/v1/Widget"] & {
+	metadata: {name: "widget", namespace: "default"}
+	spec: size: 0
+}`,
+			violations: []Violation{{Path: []string{"widget", "spec", "size"}, Message: "invalid value 0 (out of bound >=1)"}},
+		},
+		{
+			name: "valid resource",
+			model: `
+#crds: [string]: _
+widget: #crds["example.com/v1/Widget"] & {
+	metadata: {name: "widget", namespace: "default"}
+	spec: size: 1
+}`,
+		},
+		{
+			name:  "schemas are not filled when not declared",
+			model: `widget: kind: "Widget"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			schema := cuecontext.New().CompileString(tt.model)
+			nodes, err := kio.FromBytes([]byte(items))
+			require.NoError(t, err)
+
+			filled, err := FillCRDs(t.Context(), schema, nodes, CRDsFillPath)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.violations, CollectViolations(filled))
+			if tt.violations == nil {
+				require.NoError(t, filled.Validate(cue.Concrete(true)))
+			}
+		})
+	}
+}
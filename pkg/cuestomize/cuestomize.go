@@ -9,26 +9,29 @@ import (
 	"github.com/Workday/cuestomize/api"
 	"github.com/Workday/cuestomize/pkg/cuerrors"
 	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
 	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 // Cuestomize generates (or validates) resources from the provided CUE configuration and input resources.
-func Cuestomize(ctx context.Context, items []*kyaml.RNode, config *api.KRMInput, opts ...Option) ([]*kyaml.RNode, error) {
+// Stream items matched by the policies of the model are validated against (and possibly mutated with) their definitions.
+// Items exempted from the function config, or from policies, are reported as info results.
+// The results reported by the model, and the violations of its constraints and policies, are returned alongside
+// the resources, whatever their severity: it is up to the caller to fail on the ones with error severity.
+func Cuestomize(ctx context.Context, items []*kyaml.RNode, config *api.KRMInput, opts ...Option) ([]*kyaml.RNode, framework.Results, error) {
 	log := logr.FromContextOrDiscard(ctx)
 	detailer := cuerrors.FromContextOrEmpty(ctx)
 
-	var cuestomizeOpts options
-	for _, opt := range opts {
-		opt(&cuestomizeOpts)
-	}
+	cuestomizeOpts := newOptions(opts...)
 
 	if err := cuestomizeOpts.validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
 	}
+	validatorMode := GetValidatorMode(config)
 
 	err := cuestomizeOpts.ModelProvider.Get(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get CUE model from provider: %w", err)
+		return nil, nil, fmt.Errorf("failed to get CUE model from provider: %w", err)
 	}
 
 	resourcesPath := cuestomizeOpts.ModelProvider.Path()
@@ -37,47 +40,121 @@ func Cuestomize(ctx context.Context, items []*kyaml.RNode, config *api.KRMInput,
 
 	includes, err := api.ExtractIncludes(ctx, config, items)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compute includes from KRM function inputs: %w", err)
+		return nil, nil, fmt.Errorf("failed to compute includes from KRM function inputs: %w", err)
+	}
+	inputSources, err := api.ExtractInputFrom(ctx, config, items)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute inputFrom from KRM function inputs: %w", err)
+	}
+	// the values of the included Secrets, and of the Secrets the input is read from, are redacted from errors
+	if sensitive := append(includes.SensitiveValues(), api.SensitiveInputValues(inputSources)...); len(sensitive) > 0 {
+		detailer = cuerrors.NewRedactingDetailer(detailer, sensitive)
+		ctx = cuerrors.NewContext(ctx, detailer)
 	}
 	includesValue, err := includes.IntoCueValue(cueCtx)
 	if err != nil {
-		return nil, detailer.ErrorWithDetails(err, "failed to convert includes into CUE value")
+		return nil, nil, detailer.ErrorWithDetails(err, "failed to convert includes into CUE value")
 	}
 
-	configValue, err := config.IntoCueValue(cueCtx)
+	configValue, err := BuildInput(ctx, cueCtx, config, inputSources, resourcesPath)
 	if err != nil {
-		return nil, detailer.ErrorWithDetails(err, "failed to convert config into CUE value")
+		return nil, nil, fmt.Errorf("failed to build model input: %w", err)
 	}
 
-	instances, err := LoadCUEModel(ctx, resourcesPath)
+	instances, err := LoadCUEModel(ctx, resourcesPath, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load CUE model from '%s': %w", resourcesPath, err)
+		return nil, nil, fmt.Errorf("failed to load CUE model from '%s': %w", resourcesPath, err)
 	}
 
 	schema, err := BuildCUEModelSchema(ctx, cueCtx, instances)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build CUE model schema: %w", err)
+		return nil, nil, fmt.Errorf("failed to build CUE model schema: %w", err)
+	}
+
+	if err := CheckPaths(*schema, cuestomizeOpts.configuredPaths(config)); err != nil {
+		return nil, nil, fmt.Errorf("invalid CUE paths configuration: %w", err)
+	}
+	paths := cuestomizeOpts.paths(config)
+
+	unified, err := FillMetadata(ctx, *schema, config, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fill metadata in CUE schema: %w", err)
+	}
+	unified = unified.FillPath(cue.ParsePath(paths.Input), configValue)
+	unified = unified.FillPath(cue.ParsePath(paths.Includes), includesValue)
+	unified, err = FillCRDs(ctx, unified, items, paths.CRDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fill CustomResourceDefinition schemas in CUE schema: %w", err)
 	}
 
-	unified, err := FillMetadata(ctx, *schema, config)
+	var results framework.Results
+	violated := false
+	if validatorMode != "" {
+		log.V(4).Info("cuestomize is acting in validator mode.", "mode", validatorMode)
+		// every violation is reported as a result, referring to the offending included resource and field,
+		// and the run goes on, so that the results, policies and exemptions of the model are reported too
+		if violations := CollectViolations(unified); len(violations) > 0 {
+			violationResults := ViolationResults(ctx, unified, violations, items, paths.Includes)
+			RelativePositions(violationResults, resourcesPath)
+			results = ReportViolations(ctx, validatorMode, violationResults)
+			violated = true
+		}
+	}
+
+	// the violations of a validator are already reported, including the values that are not concrete
+	if !violated {
+		if unified.Err() != nil {
+			return nil, nil, detailer.ErrorWithDetails(unified.Err(), "failed to unify CUE model with inputs from KRM function")
+		}
+
+		// assert that the unified instance values are all concrete (no string, regexes, etc.)
+		// without this check, non-valorised fields can remain in output resources
+		if err := unified.Validate(cue.Final(), cue.Concrete(true)); err != nil {
+			// values of the outputs that are not concrete are listed with their likely cause, rather than every CUE error
+			if diagnostics := DiagnoseNonConcrete(ctx, unified, paths); len(diagnostics) > 0 {
+				return nil, nil, detailer.ErrorWithDetails(&NonConcreteError{Diagnostics: diagnostics, err: err}, "failed to validate unified CUE instance")
+			}
+			return nil, nil, detailer.ErrorWithDetails(err, "failed to validate unified CUE instance")
+		}
+	}
+
+	modelResults, err := CollectResults(ctx, unified, paths.Results)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fill metadata in CUE schema: %w", err)
+		return nil, nil, fmt.Errorf("failed to collect results: %w", err)
 	}
-	unified = unified.FillPath(cue.ParsePath(InputFillPath), configValue)
-	unified = unified.FillPath(cue.ParsePath(IncludesFillPath), includesValue)
-	if unified.Err() != nil {
-		return nil, detailer.ErrorWithDetails(unified.Err(), "failed to unify CUE model with inputs from KRM function")
+	results = append(results, modelResults...)
+
+	// if the function is a validator, the original items are returned without processing
+	if validatorMode == "" {
+		var schemaResults framework.Results
+		items, schemaResults, err = ProcessOutputs(ctx, unified, items, config, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, schemaResults...)
 	}
 
-	// assert that the unified instance values are all concrete (no string, regexes, etc.)
-	// without this check, non-valorised fields can remain in output resources
-	if err := unified.Validate(cue.Final(), cue.Concrete(true)); err != nil {
-		return nil, detailer.ErrorWithDetails(err, "failed to validate unified CUE instance")
+	// policies are evaluated against (and mutate, unless the validator mode only reports) the resulting stream
+	policies, err := CollectPolicies(ctx, unified, paths.Policies)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to collect policies: %w", err)
+	}
+	if !validatorMode.mutates() {
+		for i := range policies {
+			policies[i].Mutation = ""
+		}
 	}
+	items, policyResults, err := EvaluatePolicies(ctx, policies, items, config.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to evaluate policies: %w", err)
+	}
+	RelativePositions(policyResults, resourcesPath)
+	results = append(results, ReportViolations(ctx, validatorMode, policyResults)...)
 
-	if ShouldActAsValidator(config) {
-		log.V(4).Info("cuestomize is acting in validator mode.")
-		return items, nil // if the function is a validator, return the original items without processing
+	// exemptions are reported for auditing
+	exemptionResults, err := ExemptionResults(config, items, policies)
+	if err != nil {
+		return nil, nil, err
 	}
-	return ProcessOutputs(ctx, unified, items)
+	return items, append(results, exemptionResults...), nil
 }
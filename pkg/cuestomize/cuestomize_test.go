@@ -0,0 +1,140 @@
+package cuestomize
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/cuestomize/model"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+)
+
+func TestCuestomize_ValidatorWarnMode(t *testing.T) {
+	items := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: app
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: other
+  namespace: app
+spec:
+  replicas: 3
+`
+	nodes, err := kio.FromBytes([]byte(items))
+	require.NoError(t, err)
+	config := &api.KRMInput{
+		Includes: []api.IncludeSelector{{Selector: types.Selector{ResId: resid.NewResIdKindOnly("Deployment", "")}}},
+	}
+	config.APIVersion = "cuestomize.dev/v1alpha1"
+	config.Kind = "Validator"
+	config.Annotations = map[string]string{ValidatorAnnotationKey: string(ValidatorModeWarn)}
+
+	result, results, err := Cuestomize(t.Context(), nodes, config,
+		WithModelProvider(model.NewLocalPathProvider("../../testdata/function/cue-modules/validator-model")))
+
+	// the constraint violation does not stop the run: the results of the model are reported,
+	// and the items pass through unchanged, as the policies do not mutate them in warn mode
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, framework.Warning, results[0].Severity)
+	require.Equal(t, "app", results[0].ResourceRef.Name)
+	require.Equal(t, "spec.replicas", results[0].Field.Path)
+	require.Equal(t, "deployments validated", results[1].Message)
+	require.Len(t, result, 2)
+	for _, item := range result {
+		require.NotContains(t, item.GetLabels(), "team", "item %s is mutated", item.GetName())
+	}
+}
+
+func TestCuestomize_RedactsInputFromSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{
+			name:     "secret",
+			password: "hunter22",
+		},
+		{
+			name:     "short secret",
+			password: "p4s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			items := `apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+  namespace: app
+data:
+  password: ` + base64.StdEncoding.EncodeToString([]byte(tt.password)) + `
+`
+			nodes, err := kio.FromBytes([]byte(items))
+			require.NoError(t, err)
+			config := &api.KRMInput{
+				Input:     map[string]interface{}{"password": "not-the-secret"},
+				InputFrom: []api.InputFrom{{Selector: types.Selector{ResId: resid.NewResIdKindOnly("Secret", "credentials")}}},
+			}
+			config.APIVersion = "cuestomize.dev/v1alpha1"
+			config.Kind = "Validator"
+
+			_, _, err = Cuestomize(t.Context(), nodes, config,
+				WithModelProvider(model.NewLocalPathProvider("../../testdata/function/cue-modules/validator-model")))
+
+			require.ErrorContains(t, err, `conflicting values "<redacted>" and "not-the-secret"`)
+			require.NotContains(t, err.Error(), `"`+tt.password+`"`)
+		})
+	}
+}
+
+func TestCuestomize_OwnershipRerun(t *testing.T) {
+	items := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: values
+  namespace: default
+data:
+  color: blue
+`
+	nodes, err := kio.FromBytes([]byte(items))
+	require.NoError(t, err)
+	selector := types.Selector{ResId: resid.NewResIdWithNamespace(resid.Gvk{Version: "v1", Kind: "ConfigMap"}, "", "default")}
+	config := &api.KRMInput{
+		Includes:  []api.IncludeSelector{{Selector: selector}},
+		InputFrom: []api.InputFrom{{Selector: selector}},
+		Ownership: &api.Ownership{Enabled: true},
+	}
+	config.APIVersion = "cuestomize.dev/v1alpha1"
+	config.Kind = "Cuestomization"
+	config.Name = "summarizer"
+	provider := WithModelProvider(model.NewLocalPathProvider("../../testdata/function/cue-modules/ownership-model"))
+
+	first, _, err := Cuestomize(t.Context(), nodes, config, provider)
+	require.NoError(t, err)
+	firstOutput, err := kio.StringAll(first)
+	require.NoError(t, err)
+
+	// the second run gets the output of the first one, including the generated ConfigMap,
+	// which is pruned and must not be included nor used as input
+	second, _, err := Cuestomize(t.Context(), first, config, provider)
+	require.NoError(t, err)
+	secondOutput, err := kio.StringAll(second)
+	require.NoError(t, err)
+
+	require.Contains(t, firstOutput, "include-values: \"true\"")
+	require.Contains(t, firstOutput, "input-color: blue")
+	require.Equal(t, firstOutput, secondOutput)
+}
@@ -0,0 +1,90 @@
+package cuestomize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/cuerrors"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ApplyDeletions removes from the items the ones matched by the selectors found at the given deletions path
+// in the unified CUE instance. Selectors have the same fields as the include selectors: a resource ID
+// (group, version, kind, name, namespace), plus optional labelSelector and annotationSelector.
+// Each removal is logged, and each selector matching no item is returned as a warning result.
+// Deletions are optional: if the path does not exist in the unified CUE instance, items are returned unchanged.
+func ApplyDeletions(ctx context.Context, unified cue.Value, items []*kyaml.RNode, deletionsPath string) ([]*kyaml.RNode, framework.Results, error) {
+	log := logr.FromContextOrDiscard(ctx)
+	detailer := cuerrors.FromContextOrEmpty(ctx)
+
+	deletionsValue := unified.LookupPath(cue.ParsePath(deletionsPath))
+	if !deletionsValue.Exists() {
+		return items, nil, nil
+	} else if deletionsValue.Err() != nil {
+		return nil, nil, detailer.ErrorWithDetails(deletionsValue.Err(), "failed to lookup '%s' in unified CUE instance", deletionsPath)
+	}
+	deletionsIter, err := getIter(deletionsValue)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get iterator over '%s' in unified CUE instance: %v", deletionsPath, err)
+	}
+
+	var results framework.Results
+	for deletionsIter.Next() {
+		value := deletionsIter.Value()
+
+		sel, err := decodeSelector(value)
+		if err != nil {
+			return nil, nil, detailer.ErrorWithDetails(err, "failed to decode the deletion at '%v'", value.Path())
+		}
+		if sel == (types.Selector{}) {
+			return nil, nil, fmt.Errorf("the deletion at '%v' is empty, and would delete every item", value.Path())
+		}
+
+		kept := make([]*kyaml.RNode, 0, len(items))
+		for _, item := range items {
+			matches, err := api.ItemMatchReference(item, &sel)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to match item against the deletion at '%v' [%v]: %w", value.Path(), sel.String(), err)
+			}
+			if matches {
+				log.V(4).Info("deleting item from output resources", "path", value.Path().String(),
+					"kind", item.GetKind(), "apiVersion", item.GetApiVersion(), "namespace", item.GetNamespace(), "name", item.GetName())
+				continue
+			}
+			kept = append(kept, item)
+		}
+
+		if len(kept) == len(items) {
+			results = append(results, &framework.Result{
+				Message:  fmt.Sprintf("no items matched for the deletion at '%v' [%v]", value.Path(), sel.String()),
+				Severity: framework.Warning,
+			})
+		}
+		items = kept
+	}
+	return items, results, nil
+}
+
+// decodeSelector decodes a CUE value into a selector, rejecting unknown fields, so that a misspelled field
+// or a resource-shaped value (e.g. with metadata.name) fails instead of widening the selector.
+func decodeSelector(value cue.Value) (types.Selector, error) {
+	var sel types.Selector
+	data, err := value.MarshalJSON()
+	if err != nil {
+		return sel, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&sel); err != nil {
+		return sel, fmt.Errorf("invalid selector: %w", err)
+	}
+	return sel, nil
+}
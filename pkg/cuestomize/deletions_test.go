@@ -0,0 +1,113 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+)
+
+func TestApplyDeletions(t *testing.T) {
+	items := `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: default
+  namespace: app
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: legacy-config
+  namespace: app
+  labels:
+    legacy: "true"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: app
+`
+
+	tests := []struct {
+		name           string
+		model          string
+		expected       []string
+		warnings       []string
+		errorSubstring string
+	}{
+		{
+			name:     "no deletions",
+			model:    `outputs: []`,
+			expected: []string{"ServiceAccount.v1.[noGrp]/default.app", "ConfigMap.v1.[noGrp]/legacy-config.app", "Deployment.v1.apps/app.app"},
+		},
+		{
+			name:     "resid-like selector",
+			model:    `deletions: [{version: "v1", kind: "ServiceAccount", name: "default", namespace: "app"}]`,
+			expected: []string{"ConfigMap.v1.[noGrp]/legacy-config.app", "Deployment.v1.apps/app.app"},
+		},
+		{
+			name:     "label selector",
+			model:    `deletions: legacy: {kind: "ConfigMap", labelSelector: "legacy=true"}`,
+			expected: []string{"ServiceAccount.v1.[noGrp]/default.app", "Deployment.v1.apps/app.app"},
+		},
+		{
+			name:     "selector matching nothing",
+			model:    `deletions: [{kind: "Secret", name: "missing"}]`,
+			expected: []string{"ServiceAccount.v1.[noGrp]/default.app", "ConfigMap.v1.[noGrp]/legacy-config.app", "Deployment.v1.apps/app.app"},
+			warnings: []string{"no items matched for the deletion at 'deletions[0]' [Secret.[noVer].[noGrp]/missing.[noNs]:a=:l=]"},
+		},
+		{
+			name:           "empty selector",
+			model:          `deletions: [{}]`,
+			errorSubstring: "the deletion at 'deletions[0]' is empty",
+		},
+		{
+			name:           "resource-shaped selector is rejected, not widened",
+			model:          `deletions: [{apiVersion: "v1", kind: "ServiceAccount", metadata: {name: "default", namespace: "app"}}]`,
+			errorSubstring: `invalid selector: json: unknown field "apiVersion"`,
+		},
+		{
+			name:           "misspelled selector field is rejected",
+			model:          `deletions: [{kind: "ConfigMap", nmae: "legacy-config"}]`,
+			errorSubstring: `invalid selector: json: unknown field "nmae"`,
+		},
+		{
+			name:           "not a list nor a struct",
+			model:          `deletions: "all"`,
+			errorSubstring: "failed to get iterator over 'deletions'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			unified := cuecontext.New().CompileString(tt.model)
+			require.NoError(t, unified.Err())
+			nodes, err := kio.FromBytes([]byte(items))
+			require.NoError(t, err)
+
+			result, results, err := ApplyDeletions(t.Context(), unified, nodes, DeletionsPath)
+
+			if tt.errorSubstring != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+			ids := make([]string, 0, len(result))
+			for _, item := range result {
+				ids = append(ids, resid.FromRNode(item).String())
+			}
+			require.Equal(t, tt.expected, ids)
+			require.Len(t, results, len(tt.warnings))
+			for i, warning := range tt.warnings {
+				require.Equal(t, framework.Warning, results[i].Severity)
+				require.Equal(t, warning, results[i].Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package cuestomize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/cuerrors"
+)
+
+// maxReferenceDepth is the maximum number of references followed to find the cause of a non-concrete value.
+const maxReferenceDepth = 16
+
+// NonConcreteCause is the likely cause of a value of the outputs not being concrete.
+type NonConcreteCause string
+
+const (
+	// CauseMissingInputField is the cause of values depending on a field of the input that is not set.
+	CauseMissingInputField NonConcreteCause = "missing input field"
+	// CauseMissingInclude is the cause of values depending on a resource that is not included.
+	CauseMissingInclude NonConcreteCause = "missing include"
+	// CauseUnresolvedDisjunction is the cause of values that are disjunctions without a default.
+	CauseUnresolvedDisjunction NonConcreteCause = "unresolved disjunction"
+)
+
+// Diagnostic describes a value of the outputs that is not concrete.
+type Diagnostic struct {
+	// Path is the CUE path of the value, e.g. outputs.cm.metadata.name.
+	Path string
+	// Constraint is the constraint the value still carries, e.g. string or input.replicas + 1.
+	Constraint string
+	// Cause is the likely cause of the value not being concrete, empty if unknown.
+	Cause NonConcreteCause
+	// Subject is what the cause refers to: the key of the input that would satisfy the value (e.g. db.host),
+	// or the identity of the missing include (apiVersion/kind/namespace/name).
+	Subject string
+}
+
+// String returns the diagnostic with a hint on how to make the value concrete.
+func (d Diagnostic) String() string {
+	switch d.Cause {
+	case CauseMissingInputField:
+		return fmt.Sprintf("%s: %s (%s '%s': set it in the input of the function config)", d.Path, d.Constraint, d.Cause, d.Subject)
+	case CauseMissingInclude:
+		return fmt.Sprintf("%s: %s (%s '%s': add it to the includes of the function config)", d.Path, d.Constraint, d.Cause, d.Subject)
+	case CauseUnresolvedDisjunction:
+		return fmt.Sprintf("%s: %s (%s: mark a default with * in the model, or set a concrete value)", d.Path, d.Constraint, d.Cause)
+	default:
+		return fmt.Sprintf("%s: %s (not concrete)", d.Path, d.Constraint)
+	}
+}
+
+// NonConcreteError is the error of a unified CUE instance whose outputs are not concrete.
+// It wraps the validation error, while its message only lists the diagnostics.
+type NonConcreteError struct {
+	Diagnostics []Diagnostic
+	err         error
+}
+
+func (e *NonConcreteError) Error() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%d value(s) of the outputs are not concrete:", len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		msg.WriteString("\n  - " + d.String())
+	}
+	return msg.String()
+}
+
+func (e *NonConcreteError) Unwrap() error {
+	return e.err
+}
+
+// DiagnoseNonConcrete returns a diagnostic for each value of the outputs that is not concrete, classifying
+// its likely cause by following the references of the value: a reference to the input (e.g. input.replicas)
+// is a missing input field, a reference to the includes is a missing include, and a disjunction without
+// default is an unresolved disjunction.
+// Sensitive values are redacted from the constraints if the Detailer of the context redacts them.
+func DiagnoseNonConcrete(ctx context.Context, unified cue.Value, paths api.Paths) []Diagnostic {
+	diagnoser := nonConcreteDiagnoser{
+		detailer:          cuerrors.FromContextOrEmpty(ctx),
+		root:              unified,
+		inputSelectors:    cue.ParsePath(paths.Input).Selectors(),
+		includesSelectors: cue.ParsePath(paths.Includes).Selectors(),
+	}
+	diagnoser.walk(unified.LookupPath(cue.ParsePath(paths.Outputs)))
+	return diagnoser.diagnostics
+}
+
+// nonConcreteDiagnoser accumulates the diagnostics of the values that are not concrete found while walking
+// a CUE value.
+type nonConcreteDiagnoser struct {
+	detailer          cuerrors.Detailer
+	root              cue.Value
+	inputSelectors    []cue.Selector
+	includesSelectors []cue.Selector
+	diagnostics       []Diagnostic
+}
+
+// walk diagnoses the value, and its fields or elements.
+// Values are walked on their own, as CUE reports the errors of shared values at their first declaration
+// (e.g. a definition referenced by the outputs) rather than in the outputs.
+func (d *nonConcreteDiagnoser) walk(value cue.Value) {
+	switch value.IncompleteKind() {
+	case cue.ListKind:
+		if elements, err := value.List(); err == nil {
+			for elements.Next() {
+				d.walk(elements.Value())
+			}
+			return
+		}
+	case cue.StructKind:
+		if fields, err := value.Fields(cue.Optional(true)); err == nil {
+			for fields.Next() {
+				if fields.Selector().ConstraintType() != cue.OptionalConstraint {
+					d.walk(fields.Value())
+				}
+			}
+			return
+		}
+	}
+	if value.Validate(cue.Final(), cue.Concrete(true)) == nil {
+		return
+	}
+	diagnostic := Diagnostic{Path: value.Path().String(), Constraint: cuerrors.Redact(d.detailer, fmt.Sprint(value))}
+	diagnostic.Cause, diagnostic.Subject = d.cause(value, 0)
+	d.diagnostics = append(d.diagnostics, diagnostic)
+}
+
+// cause returns the likely cause of the value not being concrete, and what the cause refers to.
+func (d *nonConcreteDiagnoser) cause(value cue.Value, depth int) (NonConcreteCause, string) {
+	if depth > maxReferenceDepth {
+		return "", ""
+	}
+	if _, ref := value.ReferencePath(); len(ref.Selectors()) > 0 {
+		selectors := ref.Selectors()
+		switch {
+		case hasSelectorPrefix(selectors, d.inputSelectors):
+			return CauseMissingInputField, fieldPath(labels(cue.MakePath(selectors[len(d.inputSelectors):]...)))
+		case hasSelectorPrefix(selectors, d.includesSelectors):
+			identity := selectors[len(d.includesSelectors):]
+			identity = identity[:min(len(identity), 4)]
+			parts := make([]string, 0, len(identity))
+			for _, sel := range identity {
+				parts = append(parts, unquote(sel.String()))
+			}
+			return CauseMissingInclude, strings.Join(parts, "/")
+		}
+		return d.cause(d.root.LookupPath(ref), depth+1)
+	}
+
+	op, args := value.Expr()
+	if op == cue.NoOp {
+		return "", ""
+	}
+	for _, arg := range args {
+		if cause, subject := d.cause(arg, depth+1); cause != "" {
+			return cause, subject
+		}
+	}
+	if op == cue.OrOp {
+		return CauseUnresolvedDisjunction, ""
+	}
+	return "", ""
+}
+
+// hasSelectorPrefix tells whether the selectors start with the given (non-empty) prefix.
+func hasSelectorPrefix(selectors, prefix []cue.Selector) bool {
+	if len(prefix) == 0 || len(selectors) <= len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if selectors[i].String() != prefix[i].String() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package cuestomize
+
+import (
+	"errors"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnoseNonConcrete(t *testing.T) {
+	model := `
+input: {
+	name!:    string
+	replicas: int
+	mode:     "a" | "b"
+	db: host: string
+}
+#defaults: replicas: input.replicas
+includes: _
+outputs: [{
+	metadata: name: input.name
+	spec: {
+		replicas: #defaults.replicas
+		surge:    input.replicas + 1
+		mode:     input.mode
+		url:      "http://\(input.db.host)"
+		policy:   "Always" | "Never"
+		hostname: string
+	}
+	data: service: includes["v1"]["Service"]["default"]["app"].metadata.name
+}]
+`
+	unified := cuecontext.New().CompileString(model)
+	require.NoError(t, unified.Err())
+	err := unified.Validate(cue.Final(), cue.Concrete(true))
+	require.Error(t, err)
+
+	diagnostics := DiagnoseNonConcrete(t.Context(), unified, DefaultPaths())
+
+	require.Equal(t, []Diagnostic{
+		{Path: "outputs[0].metadata.name", Constraint: "input.name", Cause: CauseMissingInputField, Subject: "name"},
+		{Path: "outputs[0].spec.replicas", Constraint: "int", Cause: CauseMissingInputField, Subject: "replicas"},
+		{Path: "outputs[0].spec.surge", Constraint: "input.replicas + 1", Cause: CauseMissingInputField, Subject: "replicas"},
+		{Path: "outputs[0].spec.mode", Constraint: `"a" | "b"`, Cause: CauseMissingInputField, Subject: "mode"},
+		{Path: "outputs[0].spec.url", Constraint: `"http://\(input.db.host)"`, Cause: CauseMissingInputField, Subject: "db.host"},
+		{Path: "outputs[0].spec.policy", Constraint: `"Always" | "Never"`, Cause: CauseUnresolvedDisjunction},
+		{Path: "outputs[0].spec.hostname", Constraint: "string"},
+		{Path: "outputs[0].data.service", Constraint: `includes["v1"]["Service"]["default"]["app"].metadata.name`, Cause: CauseMissingInclude, Subject: "v1/Service/default/app"},
+	}, diagnostics)
+
+	nonConcreteErr := &NonConcreteError{Diagnostics: diagnostics[:2], err: err}
+	require.Equal(t, `2 value(s) of the outputs are not concrete:
+  - outputs[0].metadata.name: input.name (missing input field 'name': set it in the input of the function config)
+  - outputs[0].spec.replicas: int (missing input field 'replicas': set it in the input of the function config)`, nonConcreteErr.Error())
+	require.Equal(t, err, errors.Unwrap(nonConcreteErr))
+}
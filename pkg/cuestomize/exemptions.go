@@ -0,0 +1,56 @@
+package cuestomize
+
+import (
+	"fmt"
+
+	"github.com/Workday/cuestomize/api"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ExemptedFromTag is the tag of the results reporting exemptions, holding the name of the function config
+// or of the policy the item is exempted from.
+const ExemptedFromTag = "exempted-from"
+
+// ExemptionResults returns an info result for each item exempted from the function config, and for each item
+// exempted from a policy whose selector matches it, for auditing.
+func ExemptionResults(config *api.KRMInput, items []*kyaml.RNode, policies []Policy) (framework.Results, error) {
+	var results framework.Results
+	for _, item := range items {
+		exemption, err := api.GetExemption(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exemption: %w", err)
+		} else if exemption == nil {
+			continue
+		}
+
+		if exemption.Exempts(config.Name) {
+			results = append(results, exemptionResult(item, "function config", config.Name, exemption.Reason))
+			continue
+		}
+		for _, policy := range policies {
+			if !exemption.Exempts(policy.Name) {
+				continue
+			}
+			matches, err := api.ItemMatchReference(item, &policy.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("failed to match item against the policy at '%v' [%v]: %w", policy.Path, policy.Selector.String(), err)
+			}
+			if matches {
+				results = append(results, exemptionResult(item, "policy", policy.Name, exemption.Reason))
+			}
+		}
+	}
+	return results, nil
+}
+
+// exemptionResult returns an info result reporting the exemption of the item from a function config or a policy.
+func exemptionResult(item *kyaml.RNode, kind, name, reason string) *framework.Result {
+	return &framework.Result{
+		Message:     fmt.Sprintf("exempted from %s '%s': %s", kind, name, reason),
+		Severity:    framework.Info,
+		ResourceRef: resourceRef(item),
+		File:        itemFile(item),
+		Tags:        map[string]string{ExemptedFromTag: name},
+	}
+}
@@ -0,0 +1,85 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestExemptions(t *testing.T) {
+	items := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: legacy
+  namespace: app
+  annotations:
+    config.cuestomize.io/exempt: replicas
+    config.cuestomize.io/exempt-reason: scaled by hand
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: vendored
+  namespace: app
+  annotations:
+    config.cuestomize.io/exempt: validator
+    config.cuestomize.io/exempt-reason: vendored chart
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: app
+spec:
+  replicas: 1
+`
+	model := `
+#policies: replicas: {
+	selector: kind: "Deployment"
+	definition: {spec: {replicas: >=2, ...}, ...}
+}`
+
+	unified := cuecontext.New().CompileString(model)
+	require.NoError(t, unified.Err())
+	nodes, err := kio.FromBytes([]byte(items))
+	require.NoError(t, err)
+	config := &api.KRMInput{ObjectMeta: metav1.ObjectMeta{Name: "validator"}}
+
+	policies, err := CollectPolicies(t.Context(), unified, PoliciesPath)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	require.Equal(t, "replicas", policies[0].Name)
+
+	// only the item exempted from nothing is evaluated
+	_, results, err := EvaluatePolicies(t.Context(), policies, nodes, config.Name)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "app", results[0].ResourceRef.Name)
+
+	results, err = ExemptionResults(config, nodes, policies)
+	require.NoError(t, err)
+	require.Equal(t, framework.Results{
+		{
+			Message:     "exempted from policy 'replicas': scaled by hand",
+			Severity:    framework.Info,
+			ResourceRef: &kyaml.ResourceIdentifier{TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}, NameMeta: kyaml.NameMeta{Name: "legacy", Namespace: "app"}},
+			Tags:        map[string]string{ExemptedFromTag: "replicas"},
+		},
+		{
+			Message:     "exempted from function config 'validator': vendored chart",
+			Severity:    framework.Info,
+			ResourceRef: &kyaml.ResourceIdentifier{TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}, NameMeta: kyaml.NameMeta{Name: "vendored", Namespace: "app"}},
+			Tags:        map[string]string{ExemptedFromTag: "validator"},
+		},
+	}, results)
+}
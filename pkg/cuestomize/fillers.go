@@ -2,6 +2,7 @@ package cuestomize
 
 import (
 	"context"
+	"fmt"
 
 	"cuelang.org/go/cue"
 	"github.com/Workday/cuestomize/api"
@@ -13,9 +14,21 @@ const (
 	InputFillPath = "input"
 	// IncludesFillPath is the CUE path in which the includes will be injected into the CUE model.
 	IncludesFillPath = "includes"
+	// CRDsFillPath is the CUE path in which the schemas of the CustomResourceDefinitions of the stream will be
+	// injected into the CUE model, if the model declares it. It is a definition, as the schemas are not concrete.
+	CRDsFillPath = "#crds"
 
 	// OutputsPath is the CUE path in which the function expects the output resources (as a list) to be placed.
 	OutputsPath = "outputs"
+	// PatchesPath is the CUE path in which the function expects the (optional) patches to apply onto the stream items.
+	PatchesPath = "patches"
+	// DeletionsPath is the CUE path in which the function expects the (optional) selectors of the stream items to delete.
+	DeletionsPath = "deletions"
+	// ResultsPath is the CUE path in which the function expects the (optional) results to report in the ResourceList.
+	ResultsPath = "results"
+	// PoliciesPath is the CUE path in which the function expects the (optional) policies to evaluate the stream items against.
+	// It is a definition, so that the (non-concrete) definitions of the policies are not required to be concrete.
+	PoliciesPath = "#policies"
 )
 
 const (
@@ -27,18 +40,56 @@ const (
 	MetadataFillPath = "metadata"
 )
 
-// FillMetadata fills the CUE schema with the API version, kind, and metadata from the KRMInput configuration.
-func FillMetadata(ctx context.Context, schema cue.Value, config *api.KRMInput) (cue.Value, error) {
+// DefaultPaths returns the default CUE paths used to exchange data with the CUE model.
+func DefaultPaths() api.Paths {
+	return api.Paths{
+		Input:      InputFillPath,
+		Includes:   IncludesFillPath,
+		CRDs:       CRDsFillPath,
+		Outputs:    OutputsPath,
+		Patches:    PatchesPath,
+		Deletions:  DeletionsPath,
+		Results:    ResultsPath,
+		Policies:   PoliciesPath,
+		APIVersion: APIVersionFillPath,
+		Kind:       KindFillPath,
+		Metadata:   MetadataFillPath,
+	}
+}
+
+// CheckPaths checks that the configured (i.e. non-default) CUE paths are valid and exist in the CUE model schema.
+// Default paths are not checked, as the model is free not to declare them.
+func CheckPaths(schema cue.Value, configured api.Paths) error {
+	for _, entry := range configured.Entries() {
+		if entry.Path == "" {
+			continue
+		}
+		path := cue.ParsePath(entry.Path)
+		if path.Err() != nil {
+			return fmt.Errorf("invalid %s path '%s': %w", entry.Name, entry.Path, path.Err())
+		}
+		if !schema.LookupPath(path).Exists() {
+			return fmt.Errorf("configured %s path '%s' not found in CUE model", entry.Name, entry.Path)
+		}
+	}
+	return nil
+}
+
+// FillMetadata fills the CUE schema with the API version, kind, and metadata from the KRMInput configuration,
+// at the paths configured in the KRMInput and in the options.
+func FillMetadata(ctx context.Context, schema cue.Value, config *api.KRMInput, opts ...Option) (cue.Value, error) {
 	detailer := cuerrors.FromContextOrEmpty(ctx)
+	cuestomizeOpts := newOptions(opts...)
+	paths := cuestomizeOpts.paths(config)
 
-	filledSchema := schema.FillPath(cue.ParsePath(APIVersionFillPath), config.APIVersion)
-	filledSchema = filledSchema.FillPath(cue.ParsePath(KindFillPath), config.Kind)
+	filledSchema := schema.FillPath(cue.ParsePath(paths.APIVersion), config.APIVersion)
+	filledSchema = filledSchema.FillPath(cue.ParsePath(paths.Kind), config.Kind)
 
 	meta, err := api.IntoCueValue(schema.Context(), config.ObjectMeta)
 	if err != nil {
 		return cue.Value{}, detailer.ErrorWithDetails(err, "failed to convert ObjectMeta into CUE value")
 	}
 
-	filledSchema = filledSchema.FillPath(cue.ParsePath(MetadataFillPath), meta)
+	filledSchema = filledSchema.FillPath(cue.ParsePath(paths.Metadata), meta)
 	return filledSchema, nil
 }
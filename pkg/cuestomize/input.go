@@ -0,0 +1,84 @@
+package cuestomize
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/encoding/yaml"
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/cuerrors"
+)
+
+// BuildInput builds the CUE value of the model input from the literal input of the KRMInput,
+// from its input files (read relative to modelPath), and from the data extracted from the items
+// selected by its inputFrom entries (see api.ExtractInputFrom).
+//
+// No source takes precedence over another: all of them are unified together, so the same field
+// can be set by multiple sources only if the values agree, and a conflict is reported as a CUE error.
+func BuildInput(ctx context.Context, cueCtx *cue.Context, config *api.KRMInput, sources []api.InputSource, modelPath string) (*cue.Value, error) {
+	detailer := cuerrors.FromContextOrEmpty(ctx)
+
+	input, err := config.IntoCueValue(cueCtx)
+	if err != nil {
+		return nil, detailer.ErrorWithDetails(err, "failed to convert config into CUE value")
+	}
+
+	for _, file := range config.InputFiles {
+		fileValue, err := loadInputFile(cueCtx, modelPath, file)
+		if err != nil {
+			return nil, err
+		}
+
+		unified := input.Unify(fileValue)
+		if unified.Err() != nil {
+			return nil, detailer.ErrorWithDetails(unified.Err(), "failed to unify input file '%s' with input", file)
+		}
+		input = &unified
+	}
+
+	for _, src := range sources {
+		data, err := api.IntoCueValue(cueCtx, src.Data)
+		if err != nil {
+			return nil, detailer.ErrorWithDetails(err, "failed to convert data of %s into CUE value", src.ID)
+		}
+
+		unified := input.Unify(cueCtx.CompileString("{}").FillPath(cue.ParsePath(src.Path), *data))
+		if unified.Err() != nil {
+			if src.Path == "" {
+				return nil, detailer.ErrorWithDetails(unified.Err(), "failed to merge data of %s into input", src.ID)
+			}
+			return nil, detailer.ErrorWithDetails(unified.Err(), "failed to merge data of %s into input at '%s'", src.ID, src.Path)
+		}
+		input = &unified
+	}
+
+	return input, nil
+}
+
+// loadInputFile reads the YAML (or JSON) file at the given path, relative to modelPath, as a CUE value.
+// The value retains the positions of the file, so that errors can point at the offending line.
+func loadInputFile(cueCtx *cue.Context, modelPath, file string) (cue.Value, error) {
+	if !filepath.IsLocal(file) {
+		return cue.Value{}, fmt.Errorf("input file '%s' must be a relative path inside the CUE model", file)
+	}
+
+	path := filepath.Join(modelPath, file)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return cue.Value{}, fmt.Errorf("failed to read input file '%s': %w", file, err)
+	}
+
+	expr, err := yaml.Extract(path, content)
+	if err != nil {
+		return cue.Value{}, fmt.Errorf("failed to parse input file '%s': %w", file, err)
+	}
+
+	value := cueCtx.BuildFile(expr)
+	if value.Err() != nil {
+		return cue.Value{}, fmt.Errorf("failed to build input file '%s': %w", file, value.Err())
+	}
+	return value, nil
+}
@@ -0,0 +1,32 @@
+package cuestomize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInput_SourcesAgree(t *testing.T) {
+	modelPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(modelPath, "values.yaml"), []byte("replicas: 3\nratio: 0.5\n"), 0o600))
+
+	config := &api.KRMInput{
+		// the literal input is decoded from the function config as JSON, i.e. with float64 numbers
+		Input:      map[string]interface{}{"replicas": float64(3), "ratio": 0.5},
+		InputFiles: []string{"values.yaml"},
+	}
+	sources := []api.InputSource{{Data: map[string]interface{}{"replicas": float64(3)}}}
+
+	input, err := BuildInput(t.Context(), cuecontext.New(), config, sources, modelPath)
+
+	require.NoError(t, err)
+	replicas := input.LookupPath(cue.ParsePath("replicas"))
+	require.Equal(t, cue.IntKind, replicas.Kind())
+	ratio := input.LookupPath(cue.ParsePath("ratio"))
+	require.Equal(t, cue.FloatKind, ratio.Kind())
+}
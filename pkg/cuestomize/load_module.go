@@ -3,18 +3,130 @@ package cuestomize
 import (
 	"context"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"cuelang.org/go/cue/build"
 	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/parser"
+	"github.com/Workday/cuestomize/api"
+	"github.com/go-logr/logr"
 )
 
 // LoadCUEModel loads a CUE model from the specified path and returns the instances.
-func LoadCUEModel(ctx context.Context, path string) ([]*build.Instance, error) {
-	cfg := &load.Config{Dir: path}
-	instances := load.Instances([]string{"."}, cfg)
+// The package (and the directory of the module) to load are selected by the config, whose tags are injected
+// into the model, and whose build tags select the files of the model.
+func LoadCUEModel(ctx context.Context, path string, config *api.KRMInput) ([]*build.Instance, error) {
+	log := logr.FromContextOrDiscard(ctx)
+
+	arg, err := instanceArg(path, config)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := loadTags(config)
+	if err != nil {
+		return nil, err
+	}
+	log.V(4).Info("loading CUE model", "instance", arg, "tags", tags)
+
+	cfg := &load.Config{Dir: path, Tags: tags, TagVars: load.DefaultTagVars()}
+	instances := load.Instances([]string{arg}, cfg)
 	if len(instances) == 0 {
 		return nil, fmt.Errorf("no CUE instances found")
 	}
 
-	return instances, CheckInstances(ctx, instances)
+	if err := CheckInstances(ctx, instances); err != nil {
+		if packageErr := checkPackage(path, config); packageErr != nil {
+			return nil, packageErr
+		}
+		return nil, err
+	}
+	return instances, nil
+}
+
+// instanceArg returns the argument of the CUE loader selecting the directory and the package of the config,
+// e.g. ./apps/web:web.
+func instanceArg(path string, config *api.KRMInput) (string, error) {
+	dir := filepath.Clean(config.Dir)
+	if filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return "", fmt.Errorf("invalid dir '%s', must be relative to the root of the CUE module and inside it", config.Dir)
+	}
+
+	arg := "."
+	if dir != "." {
+		if info, err := os.Stat(filepath.Join(path, dir)); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("dir '%s' not found in the CUE module", config.Dir)
+		}
+		arg = "./" + filepath.ToSlash(dir)
+	}
+	if config.Package != "" {
+		arg += ":" + config.Package
+	}
+	return arg, nil
+}
+
+// checkPackage returns an error listing the packages available in the directory of the config, if the package
+// of the config is not one of them, or if the config selects none while the directory holds several.
+func checkPackage(path string, config *api.KRMInput) error {
+	packages, err := availablePackages(filepath.Join(path, filepath.Clean(config.Dir)))
+	if err != nil || len(packages) == 0 {
+		return nil
+	}
+	dir := config.Dir
+	if dir == "" {
+		dir = "."
+	}
+	switch {
+	case config.Package != "" && !slices.Contains(packages, config.Package):
+		return fmt.Errorf("package '%s' not found in dir '%s' of the CUE module, available packages: %s",
+			config.Package, dir, strings.Join(packages, ", "))
+	case config.Package == "" && len(packages) > 1:
+		return fmt.Errorf("dir '%s' of the CUE module holds several packages, select one with 'package', available packages: %s",
+			dir, strings.Join(packages, ", "))
+	}
+	return nil
+}
+
+// availablePackages returns the sorted names of the packages of the CUE files in the directory.
+func availablePackages(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	packages := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".cue" {
+			continue
+		}
+		file, err := parser.ParseFile(filepath.Join(dir, entry.Name()), nil, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+		if name := file.PackageName(); name != "" {
+			packages[name] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(packages)), nil
+}
+
+// loadTags returns the tags of the config in the format of the CUE loader: key=value for the tags injected
+// into the fields of the model (sorted by key), and key for the build tags.
+func loadTags(config *api.KRMInput) ([]string, error) {
+	tags := make([]string, 0, len(config.Tags)+len(config.BuildTags))
+	for _, key := range slices.Sorted(maps.Keys(config.Tags)) {
+		if key == "" || strings.Contains(key, "=") {
+			return nil, fmt.Errorf("invalid tag '%s', tag names must be non-empty and must not contain '='", key)
+		}
+		tags = append(tags, key+"="+config.Tags[key])
+	}
+	for _, buildTag := range config.BuildTags {
+		if buildTag == "" || strings.Contains(buildTag, "=") {
+			return nil, fmt.Errorf("invalid build tag '%s', build tags must be non-empty and must not contain '='", buildTag)
+		}
+		tags = append(tags, buildTag)
+	}
+	return tags, nil
 }
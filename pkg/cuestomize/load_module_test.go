@@ -0,0 +1,70 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCUEModel_Package(t *testing.T) {
+	const modelPath = "../../testdata/function/cue-modules/multi-package-model"
+
+	tests := []struct {
+		name           string
+		dir            string
+		pkg            string
+		expected       string
+		errorSubstring string
+	}{
+		{
+			name:     "package of the directory",
+			dir:      "apps/worker",
+			expected: "worker",
+		},
+		{
+			name:     "package selected among the packages of the directory",
+			dir:      "generators",
+			pkg:      "alpha",
+			expected: "alpha",
+		},
+		{
+			name:           "package not found lists the available packages",
+			dir:            "generators",
+			pkg:            "gamma",
+			errorSubstring: "package 'gamma' not found in dir 'generators' of the CUE module, available packages: alpha, beta",
+		},
+		{
+			name:           "directory holding several packages",
+			dir:            "generators/",
+			errorSubstring: "select one with 'package', available packages: alpha, beta",
+		},
+		{
+			name:           "directory not found",
+			dir:            "apps/api",
+			errorSubstring: "dir 'apps/api' not found in the CUE module",
+		},
+		{
+			name:           "directory outside of the module",
+			dir:            "apps/../..",
+			errorSubstring: "must be relative to the root of the CUE module and inside it",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			config := &api.KRMInput{Dir: tt.dir, Package: tt.pkg}
+
+			instances, err := LoadCUEModel(t.Context(), modelPath, config)
+
+			if tt.errorSubstring != "" {
+				require.ErrorContains(t, err, tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, instances, 1)
+			require.Equal(t, tt.expected, instances[0].PkgName)
+		})
+	}
+}
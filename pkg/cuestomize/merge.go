@@ -0,0 +1,122 @@
+package cuestomize
+
+import (
+	"fmt"
+
+	"github.com/Workday/cuestomize/api"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/merge2"
+)
+
+// MergeOutput adds an output to the items. If an item with the same resource ID is already present,
+// the output is merged onto it according to the merge policy.
+func MergeOutput(items []*kyaml.RNode, output *kyaml.RNode, policy api.MergePolicy) ([]*kyaml.RNode, error) {
+	index := indexOf(items, resid.FromRNode(output))
+	if index < 0 {
+		return append(items, output), nil
+	}
+
+	merged, err := merge(items[index], output, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge output %s with the item in the stream: %w", resid.FromRNode(output), err)
+	}
+	items[index] = merged
+	return items, nil
+}
+
+// merge merges the output onto the item according to the merge policy, and returns the result.
+func merge(item, output *kyaml.RNode, policy api.MergePolicy) (*kyaml.RNode, error) {
+	switch policy.OrDefault() {
+	case api.MergePolicyError:
+		return nil, fmt.Errorf("an item with the same resource ID is already in the stream (merge policy is %s)", api.MergePolicyError)
+	case api.MergePolicyReplace:
+		if err := copyLocationAnnotations(item, output); err != nil {
+			return nil, err
+		}
+		return output, nil
+	case api.MergePolicyStrategicMerge:
+		return merge2.Merge(output, item, kyaml.MergeOptions{ListIncreaseDirection: kyaml.MergeOptionsListAppend})
+	case api.MergePolicyJSONMerge:
+		return jsonMerge(item, output)
+	default:
+		return nil, policy.Validate()
+	}
+}
+
+// jsonMerge applies the patch onto the item as a JSON merge patch (RFC 7386).
+func jsonMerge(item, patch *kyaml.RNode) (*kyaml.RNode, error) {
+	target, err := item.Map()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert item to map: %w", err)
+	}
+	patchMap, err := patch.Map()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert patch to map: %w", err)
+	}
+
+	merged, _ := jsonMergePatch(target, patchMap).(map[string]interface{})
+	return kyaml.FromMap(merged)
+}
+
+// jsonMergePatch implements the MergePatch algorithm of RFC 7386.
+func jsonMergePatch(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetMap, ok := target.(map[string]interface{})
+	if !ok {
+		targetMap = make(map[string]interface{}, len(patchMap))
+	}
+	for k, v := range patchMap {
+		if v == nil {
+			delete(targetMap, k)
+			continue
+		}
+		targetMap[k] = jsonMergePatch(targetMap[k], v)
+	}
+	return targetMap
+}
+
+// indexOf returns the index of the item with the given resource ID, or -1 if none is found.
+func indexOf(items []*kyaml.RNode, id resid.ResId) int {
+	for i, item := range items {
+		if resid.FromRNode(item).Equals(id) {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyLocationAnnotations copies the annotations recording the file of an item to another one,
+// unless the latter already has its own, so that it is written where the former was.
+func copyLocationAnnotations(from, to *kyaml.RNode) error {
+	if path, _, _ := kioutil.GetFileAnnotations(to); path != "" {
+		return nil
+	}
+	path, index, _ := kioutil.GetFileAnnotations(from)
+	return setLocationAnnotations(to, path, index)
+}
+
+// setLocationAnnotations sets the (internal and legacy) annotations recording the file of an item,
+// and its index in the file. Empty values are not set.
+func setLocationAnnotations(node *kyaml.RNode, path, index string) error {
+	annotations := [][2]string{
+		{kioutil.PathAnnotation, path},
+		{kioutil.IndexAnnotation, index},
+		{kioutil.LegacyPathAnnotation, path},
+		{kioutil.LegacyIndexAnnotation, index},
+	}
+	for _, annotation := range annotations {
+		key, value := annotation[0], annotation[1]
+		if value == "" {
+			continue
+		}
+		if err := node.PipeE(kyaml.SetAnnotation(key, value)); err != nil {
+			return fmt.Errorf("failed to set annotation '%s': %w", key, err)
+		}
+	}
+	return nil
+}
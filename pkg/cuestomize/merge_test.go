@@ -0,0 +1,212 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const testDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    config.kubernetes.io/path: app.yaml
+    internal.config.kubernetes.io/path: app.yaml
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+`
+
+func TestMergeOutput(t *testing.T) {
+	output := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: sidecar:v1
+`
+
+	tests := []struct {
+		name           string
+		policy         api.MergePolicy
+		expected       string
+		errorSubstring string
+	}{
+		{
+			name:           "default policy fails",
+			policy:         "",
+			errorSubstring: "an item with the same resource ID is already in the stream",
+		},
+		{
+			name:   "replace keeps the file annotations",
+			policy: api.MergePolicyReplace,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    internal.config.kubernetes.io/path: 'app.yaml'
+    config.kubernetes.io/path: 'app.yaml'
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: sidecar:v1
+`,
+		},
+		{
+			name:   "strategic merge merges containers by name",
+			policy: api.MergePolicyStrategicMerge,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    config.kubernetes.io/path: app.yaml
+    internal.config.kubernetes.io/path: app.yaml
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+      - name: sidecar
+        image: sidecar:v1
+`,
+		},
+		{
+			name:   "json merge replaces lists",
+			policy: api.MergePolicyJSONMerge,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    config.kubernetes.io/path: app.yaml
+    internal.config.kubernetes.io/path: app.yaml
+  name: app
+  namespace: default
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - image: sidecar:v1
+        name: sidecar
+`,
+		},
+		{
+			name:           "unknown policy",
+			policy:         "overwrite",
+			errorSubstring: `unsupported merge policy "overwrite"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			items := []*kyaml.RNode{kyaml.MustParse(testDeployment)}
+
+			result, err := MergeOutput(items, kyaml.MustParse(output), tt.policy)
+
+			if tt.errorSubstring != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, result, 1)
+			require.Equal(t, tt.expected, result[0].MustString())
+		})
+	}
+}
+
+func TestApplyPatches(t *testing.T) {
+	tests := []struct {
+		name           string
+		model          string
+		expected       string
+		errorSubstring string
+	}{
+		{
+			name:     "no patches",
+			model:    `outputs: []`,
+			expected: testDeployment,
+		},
+		{
+			name: "patch injecting a sidecar",
+			model: `patches: [{
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata: {name: "app", namespace: "default"}
+	spec: template: spec: containers: [{name: "sidecar", image: "sidecar:v1"}]
+}]`,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  annotations:
+    config.kubernetes.io/path: app.yaml
+    internal.config.kubernetes.io/path: app.yaml
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+      - name: sidecar
+        image: sidecar:v1
+`,
+		},
+		{
+			name: "patch not matching any item",
+			model: `patches: missing: {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata: name: "missing"
+}`,
+			errorSubstring: "no item matches the patch at 'patches.missing'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			unified := cuecontext.New().CompileString(tt.model)
+			require.NoError(t, unified.Err())
+			items := []*kyaml.RNode{kyaml.MustParse(testDeployment)}
+
+			result, err := ApplyPatches(t.Context(), unified, items, "", PatchesPath)
+
+			if tt.errorSubstring != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, result, 1)
+			require.Equal(t, tt.expected, result[0].MustString())
+		})
+	}
+}
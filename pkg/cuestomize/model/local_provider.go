@@ -24,3 +24,8 @@ func NewLocalPathProvider(resourcesPath string) *LocalPathProvider {
 func (p *LocalPathProvider) Get(_ context.Context) error {
 	return nil
 }
+
+// Source returns the local file system path the CUE model is loaded from.
+func (p *LocalPathProvider) Source() Source {
+	return Source{Location: p.resourcesPath}
+}
@@ -64,6 +64,9 @@ type OCIModelProvider struct {
 	plainHTTP  bool
 	workingDir string
 	client     *auth.Client
+
+	// digest is the digest of the fetched artifact, set by Get.
+	digest string
 }
 
 // NewOCIModelProviderFromConfigAndItems creates a new OCIModelProvider based on the provided KRMInput configuration and input items.
@@ -124,7 +127,7 @@ func (p *OCIModelProvider) Get(ctx context.Context) error {
 
 	log.Info("fetching from OCI registry", "plainHTTP", p.plainHTTP)
 
-	err := fetcher.FetchFromOCIRegistry(
+	desc, err := fetcher.FetchFromOCIRegistry(
 		ctx,
 		p.client,
 		p.workingDir,
@@ -136,6 +139,7 @@ func (p *OCIModelProvider) Get(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to fetch from OCI registry: %w", err)
 	}
+	p.digest = desc.Digest.String()
 
 	// best-effort validation of module structure
 	_, err = os.Stat(filepath.Join(p.workingDir, "cue.mod"))
@@ -145,3 +149,8 @@ func (p *OCIModelProvider) Get(ctx context.Context) error {
 
 	return nil
 }
+
+// Source returns the registry, repository, tag and resolved digest of the CUE model.
+func (p *OCIModelProvider) Source() Source {
+	return Source{Location: p.registry + "/" + p.repo, Tag: p.tag, Digest: p.digest}
+}
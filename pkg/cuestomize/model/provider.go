@@ -15,3 +15,19 @@ type Provider interface {
 	// Path returns the file system path where the CUE model is located.
 	Path() string
 }
+
+// Source describes where a CUE model comes from.
+type Source struct {
+	// Location is the local path, or the registry and repository, of the CUE model.
+	Location string
+	// Tag is the tag the CUE model was fetched with, if any.
+	Tag string
+	// Digest is the resolved digest of the CUE model, if any.
+	Digest string
+}
+
+// Describer is implemented by the providers that can describe the source of the CUE model they provide.
+type Describer interface {
+	// Source returns the source of the CUE model. It is only meaningful after Get has succeeded.
+	Source() Source
+}
@@ -3,6 +3,7 @@ package cuestomize
 import (
 	"fmt"
 
+	"github.com/Workday/cuestomize/api"
 	"github.com/Workday/cuestomize/pkg/cuestomize/model"
 )
 
@@ -12,6 +13,35 @@ type Option func(*options)
 // options holds configuration options for the Cuestomize function.
 type options struct {
 	ModelProvider model.Provider
+	// Paths holds the CUE paths set through options, which take precedence over the ones in the KRMInput.
+	Paths api.Paths
+}
+
+// newOptions returns the options resulting from applying the given functional options.
+func newOptions(opts ...Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// configuredPaths returns the CUE paths configured in the KRMInput, overridden by the ones set through options.
+func (o *options) configuredPaths(config *api.KRMInput) api.Paths {
+	return config.ConfiguredPaths().Merge(o.Paths)
+}
+
+// paths returns the CUE paths to use, i.e. the configured ones, with defaults for the others.
+func (o *options) paths(config *api.KRMInput) api.Paths {
+	return DefaultPaths().Merge(o.configuredPaths(config))
+}
+
+// modelSource returns the source of the CUE model, if the model provider can describe it.
+func (o *options) modelSource() model.Source {
+	if describer, ok := o.ModelProvider.(model.Describer); ok {
+		return describer.Source()
+	}
+	return model.Source{}
 }
 
 func (o *options) validate() error {
@@ -27,3 +57,26 @@ func WithModelProvider(provider model.Provider) Option {
 		opts.ModelProvider = provider
 	}
 }
+
+// WithPaths sets the CUE paths used to exchange data with the CUE model.
+// Non-empty paths take precedence over the ones configured in the KRMInput.
+func WithPaths(paths api.Paths) Option {
+	return func(opts *options) {
+		opts.Paths = opts.Paths.Merge(paths)
+	}
+}
+
+// WithInputPath sets the CUE path in which the input is filled.
+func WithInputPath(path string) Option {
+	return WithPaths(api.Paths{Input: path})
+}
+
+// WithIncludesPath sets the CUE path in which the includes are filled.
+func WithIncludesPath(path string) Option {
+	return WithPaths(api.Paths{Includes: path})
+}
+
+// WithOutputsPath sets the CUE path from which the generated resources are read.
+func WithOutputsPath(path string) Option {
+	return WithPaths(api.Paths{Outputs: path})
+}
@@ -0,0 +1,107 @@
+package cuestomize
+
+import (
+	"sort"
+
+	"github.com/Workday/cuestomize/api"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+)
+
+// installOrder is the order in which Helm installs resources, by kind.
+var installOrder = []string{
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"SecretList",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleList",
+	"ClusterRoleBinding",
+	"ClusterRoleBindingList",
+	"Role",
+	"RoleList",
+	"RoleBinding",
+	"RoleBindingList",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// installRank maps each kind of installOrder to its position.
+var installRank = func() map[string]int {
+	rank := make(map[string]int, len(installOrder))
+	for i, kind := range installOrder {
+		rank[kind] = i
+	}
+	return rank
+}()
+
+// SortOutputs sorts the outputs in place according to the given order.
+// In install order, kinds unknown to Helm are placed after the known ones, sorted alphabetically.
+func SortOutputs(outputs []Output, order api.OutputOrder) error {
+	if err := order.Validate(); err != nil {
+		return err
+	}
+
+	switch order {
+	case api.OutputOrderResID:
+		sort.SliceStable(outputs, func(i, j int) bool {
+			return residLess(resid.FromRNode(outputs[i].Node), resid.FromRNode(outputs[j].Node))
+		})
+	case api.OutputOrderInstall:
+		sort.SliceStable(outputs, func(i, j int) bool {
+			idI, idJ := resid.FromRNode(outputs[i].Node), resid.FromRNode(outputs[j].Node)
+			if rankI, rankJ := kindRank(idI.Kind), kindRank(idJ.Kind); rankI != rankJ {
+				return rankI < rankJ
+			}
+			// unknown kinds share the same rank, and are sorted alphabetically
+			if idI.Kind != idJ.Kind {
+				return idI.Kind < idJ.Kind
+			}
+			return residLess(idI, idJ)
+		})
+	}
+	return nil
+}
+
+// kindRank returns the position of the kind in the install order, or the length of the install order
+// if the kind is unknown to it.
+func kindRank(kind string) int {
+	if rank, ok := installRank[kind]; ok {
+		return rank
+	}
+	return len(installOrder)
+}
+
+// residLess compares two resource IDs by group, version, kind, namespace and name.
+func residLess(a, b resid.ResId) bool {
+	keysA := []string{a.Group, a.Version, a.Kind, a.Namespace, a.Name}
+	keysB := []string{b.Group, b.Version, b.Kind, b.Namespace, b.Name}
+	for i := range keysA {
+		if keysA[i] != keysB[i] {
+			return keysA[i] < keysB[i]
+		}
+	}
+	return false
+}
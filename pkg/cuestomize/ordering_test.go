@@ -0,0 +1,64 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestSortOutputs(t *testing.T) {
+	newOutputs := func() []Output {
+		nodes := []string{
+			"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: app\n",
+			"apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: widget\n",
+			"apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n  namespace: app\n",
+			"apiVersion: example.com/v1\nkind: Gadget\nmetadata:\n  name: gadget\n",
+			"apiVersion: v1\nkind: Namespace\nmetadata:\n  name: app\n",
+			"apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: reader\n",
+		}
+		outputs := make([]Output, 0, len(nodes))
+		for _, node := range nodes {
+			outputs = append(outputs, Output{Node: kyaml.MustParse(node)})
+		}
+		return outputs
+	}
+
+	tests := []struct {
+		name     string
+		order    api.OutputOrder
+		expected []string
+	}{
+		{
+			name:     "model order",
+			order:    api.OutputOrderModel,
+			expected: []string{"Deployment", "Widget", "Service", "Gadget", "Namespace", "ClusterRole"},
+		},
+		{
+			name:     "resid order",
+			order:    api.OutputOrderResID,
+			expected: []string{"Namespace", "Service", "Deployment", "Gadget", "Widget", "ClusterRole"},
+		},
+		{
+			name:     "install order",
+			order:    api.OutputOrderInstall,
+			expected: []string{"Namespace", "ClusterRole", "Service", "Deployment", "Gadget", "Widget"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			outputs := newOutputs()
+
+			require.NoError(t, SortOutputs(outputs, tt.order))
+
+			kinds := make([]string, 0, len(outputs))
+			for _, output := range outputs {
+				kinds = append(kinds, output.Node.GetKind())
+			}
+			require.Equal(t, tt.expected, kinds)
+		})
+	}
+}
@@ -6,42 +6,159 @@ import (
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/encoding/yaml"
+	"github.com/Workday/cuestomize/api"
 	"github.com/Workday/cuestomize/pkg/cuerrors"
 	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
 
 	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
-// ProcessOutputs processes the outputs from the CUE model and appends them to the output slice.
-func ProcessOutputs(ctx context.Context, unified cue.Value, items []*kyaml.RNode) ([]*kyaml.RNode, error) {
+// Output is a resource generated by the CUE model.
+type Output struct {
+	// Path is the path of the output in the unified CUE instance.
+	Path cue.Path
+	// Node is the generated resource.
+	Node *kyaml.RNode
+}
+
+// ProcessOutputs collects the outputs of the CUE model, in the configured output order, and adds them to the
+// items. If enabled, the outputs are first validated against the Kubernetes schemas, stamped with provenance
+// annotations (describing the source of the model with the model provider set in opts, if any), and marked as
+// owned by the KRMInput. The items matched by include selectors marked as consume or by the deletions of the
+// model are then removed, as are the items the KRMInput generated on a previous run, if ownership is enabled.
+// Outputs are placed in the files set by the model or rendered from the path template, added according to the
+// merge policy when they have the same resource ID as an item, and the patches of the model are applied last.
+// Schema violations make it fail, while the unknown fields reported as warnings and the deletions matching no
+// item are returned as results. CUE paths are the ones configured in the KRMInput and in opts.
+func ProcessOutputs(ctx context.Context, unified cue.Value, items []*kyaml.RNode, config *api.KRMInput, opts ...Option) ([]*kyaml.RNode, framework.Results, error) {
 	log := logr.FromContextOrDiscard(ctx)
+	cuestomizeOpts := newOptions(opts...)
+	paths := cuestomizeOpts.paths(config)
+
+	outputs, err := CollectOutputs(ctx, unified, paths.Outputs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := SortOutputs(outputs, config.OutputOrder); err != nil {
+		return nil, nil, fmt.Errorf("failed to sort outputs: %w", err)
+	}
+
+	results, err := ValidateOutputs(ctx, outputs, items, config.SchemaValidation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := StampProvenance(outputs, config, cuestomizeOpts.modelSource()); err != nil {
+		return nil, nil, err
+	}
 
+	if err := StampOwnership(outputs, config); err != nil {
+		return nil, nil, err
+	}
+
+	items, err = RemoveConsumedIncludes(ctx, config, items)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to remove consumed includes: %w", err)
+	}
+
+	items, deletionResults, err := ApplyDeletions(ctx, unified, items, paths.Deletions)
+	if err != nil {
+		return nil, nil, err
+	}
+	results = append(results, deletionResults...)
+
+	items, err = PruneOwned(ctx, config, items, outputs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := PlaceOutputs(outputs, items, config.PathTemplate); err != nil {
+		return nil, nil, err
+	}
+
+	if err := CheckConflicts(outputs, items, config.MergePolicy); err != nil {
+		return nil, nil, err
+	}
+
+	for _, output := range outputs {
+		rNode := output.Node
+		log.V(4).Info("adding item to output resources", "path", output.Path.String(),
+			"kind", rNode.GetKind(), "apiVersion", rNode.GetApiVersion(), "namespace", rNode.GetNamespace(), "name", rNode.GetName())
+		items, err = MergeOutput(items, rNode, config.MergePolicy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to add output at '%v': %w", output.Path, err)
+		}
+	}
+
+	items, err = ApplyPatches(ctx, unified, items, config.MergePolicy, paths.Patches)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, results, nil
+}
+
+// CollectOutputs collects the resources found at the given outputs path in the unified CUE instance.
+// Nested lists and structs are flattened down to the KRM objects (values with apiVersion and kind) they
+// contain, and the items of v1/List objects are expanded. Any other leaf value is reported as an error.
+func CollectOutputs(ctx context.Context, unified cue.Value, outputsPath string) ([]Output, error) {
 	detailer := cuerrors.FromContextOrEmpty(ctx)
 
-	outputsValue := unified.LookupPath(cue.ParsePath(OutputsPath))
+	outputsValue := unified.LookupPath(cue.ParsePath(outputsPath))
 	if !outputsValue.Exists() {
-		return nil, fmt.Errorf("'%s' not found in unified CUE instance", OutputsPath)
+		return nil, fmt.Errorf("'%s' not found in unified CUE instance", outputsPath)
 	} else if outputsValue.Err() != nil {
-		return nil, detailer.ErrorWithDetails(outputsValue.Err(), "failed to lookup '%s' in unified CUE instance", OutputsPath)
+		return nil, detailer.ErrorWithDetails(outputsValue.Err(), "failed to lookup '%s' in unified CUE instance", outputsPath)
 	}
-	outputsIter, err := getIter(outputsValue)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get iterator over '%s' in unified CUE instance: %v", OutputsPath, err)
+	if _, err := getIter(outputsValue); err != nil {
+		return nil, fmt.Errorf("failed to get iterator over '%s' in unified CUE instance: %v", outputsPath, err)
 	}
 
-	for outputsIter.Next() {
-		item := outputsIter.Value()
+	return flattenOutputs(outputsValue, nil)
+}
 
-		rNode, err := cueValueToRNode(&item)
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert CUE value to kyaml.RNode: %w", err)
+// flattenOutputs appends to outputs the KRM objects found in value, recursing into lists and structs
+// that are not KRM objects themselves, and expanding the items of v1/List objects.
+func flattenOutputs(value cue.Value, outputs []Output) ([]Output, error) {
+	if value.Kind() == cue.StructKind {
+		hasAPIVersion := value.LookupPath(cue.MakePath(cue.Str("apiVersion"))).Exists()
+		hasKind := value.LookupPath(cue.MakePath(cue.Str("kind"))).Exists()
+		switch {
+		case hasAPIVersion && hasKind:
+			if isListObject(value) {
+				return flattenOutputs(value.LookupPath(cue.MakePath(cue.Str("items"))), outputs)
+			}
+			rNode, err := cueValueToRNode(&value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert CUE value to kyaml.RNode: %w", err)
+			}
+			return append(outputs, Output{Path: value.Path(), Node: rNode}), nil
+		case hasAPIVersion:
+			return nil, fmt.Errorf("output at '%v' has an apiVersion but no kind", value.Path())
+		case hasKind:
+			return nil, fmt.Errorf("output at '%v' has a kind but no apiVersion", value.Path())
 		}
+	}
 
-		log.V(4).Info("adding item to output resources",
-			"kind", rNode.GetKind(), "apiVersion", rNode.GetApiVersion(), "namespace", rNode.GetNamespace(), "name", rNode.GetName())
-		items = append(items, rNode)
+	iter, err := getIter(value)
+	if err != nil {
+		return nil, fmt.Errorf("output at '%v' is not a KRM object (with apiVersion and kind), nor a list or struct of KRM objects: %v", value.Path(), err)
 	}
-	return items, nil
+	for iter.Next() {
+		outputs, err = flattenOutputs(iter.Value(), outputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
+}
+
+// isListObject tells whether the value is a v1/List object, whose items are KRM objects.
+func isListObject(value cue.Value) bool {
+	apiVersion, _ := value.LookupPath(cue.MakePath(cue.Str("apiVersion"))).String()
+	kind, _ := value.LookupPath(cue.MakePath(cue.Str("kind"))).String()
+	return apiVersion == "v1" && kind == "List"
 }
 
 // getIter returns a cue.Iterator over a cue.Value of kind list or struct.
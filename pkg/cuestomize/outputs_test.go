@@ -0,0 +1,92 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectOutputs(t *testing.T) {
+	tests := []struct {
+		name           string
+		model          string
+		expectedPaths  []string
+		errorSubstring string
+	}{
+		{
+			name: "flat list",
+			model: `outputs: [
+	{apiVersion: "v1", kind: "ConfigMap", metadata: name: "a"},
+	{apiVersion: "v1", kind: "ConfigMap", metadata: name: "b"},
+]`,
+			expectedPaths: []string{"outputs[0]", "outputs[1]"},
+		},
+		{
+			name: "nested lists and structs",
+			model: `outputs: {
+	app: [
+		{apiVersion: "apps/v1", kind: "Deployment", metadata: name: "app"},
+		[{apiVersion: "v1", kind: "Service", metadata: name: "app"}],
+	]
+	rbac: {
+		sa: {apiVersion: "v1", kind: "ServiceAccount", metadata: name: "app"}
+		bindings: {}
+	}
+}`,
+			expectedPaths: []string{"outputs.app[0]", "outputs.app[1][0]", "outputs.rbac.sa"},
+		},
+		{
+			name: "list kind is expanded",
+			model: `outputs: list: {
+	apiVersion: "v1"
+	kind:       "List"
+	items: [
+		{apiVersion: "v1", kind: "ConfigMap", metadata: name: "a"},
+		{apiVersion: "v1", kind: "ConfigMap", metadata: name: "b"},
+	]
+}`,
+			expectedPaths: []string{"outputs.list.items[0]", "outputs.list.items[1]"},
+		},
+		{
+			name: "leaf which is not a KRM object",
+			model: `outputs: app: {
+	cm: {apiVersion: "v1", kind: "ConfigMap", metadata: name: "a"}
+	replicas: 3
+}`,
+			errorSubstring: "output at 'outputs.app.replicas' is not a KRM object",
+		},
+		{
+			name:           "object without kind",
+			model:          `outputs: cm: {apiVersion: "v1", metadata: name: "a"}`,
+			errorSubstring: "output at 'outputs.cm' has an apiVersion but no kind",
+		},
+		{
+			name:           "outputs is not a list nor a struct",
+			model:          `outputs: "nope"`,
+			errorSubstring: "failed to get iterator over 'outputs'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			unified := cuecontext.New().CompileString(tt.model)
+			require.NoError(t, unified.Err())
+
+			outputs, err := CollectOutputs(t.Context(), unified, OutputsPath)
+
+			if tt.errorSubstring != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+			paths := make([]string, 0, len(outputs))
+			for _, output := range outputs {
+				paths = append(paths, output.Path.String())
+			}
+			require.Equal(t, tt.expectedPaths, paths)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package cuestomize
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// StampOwnership marks the outputs as owned by the function config, if ownership is enabled.
+func StampOwnership(outputs []Output, config *api.KRMInput) error {
+	if config.Ownership == nil || !config.Ownership.Enabled {
+		return nil
+	}
+
+	for _, output := range outputs {
+		if err := output.Node.PipeE(kyaml.SetAnnotation(api.OwnedByAnnotation, config.Name)); err != nil {
+			return fmt.Errorf("failed to set annotation '%s' on output at '%v': %w", api.OwnedByAnnotation, output.Path, err)
+		}
+	}
+	return nil
+}
+
+// PruneOwned removes from the items the ones owned by the function config, i.e. the resources it generated
+// on a previous run, if ownership is enabled.
+// Outputs replacing a pruned item inherit its file annotations, so that they are written where it was,
+// while pruned items that are not generated anymore are dropped.
+func PruneOwned(ctx context.Context, config *api.KRMInput, items []*kyaml.RNode, outputs []Output) ([]*kyaml.RNode, error) {
+	if config.Ownership == nil || !config.Ownership.Enabled {
+		return items, nil
+	}
+	log := logr.FromContextOrDiscard(ctx)
+
+	kept := make([]*kyaml.RNode, 0, len(items))
+	for _, item := range items {
+		if !config.Owns(item) {
+			kept = append(kept, item)
+			continue
+		}
+
+		id := resid.FromRNode(item)
+		replaced := false
+		for _, output := range outputs {
+			if !resid.FromRNode(output.Node).Equals(id) {
+				continue
+			}
+			if err := copyLocationAnnotations(item, output.Node); err != nil {
+				return nil, fmt.Errorf("failed to copy file annotations of %s onto output at '%v': %w", id, output.Path, err)
+			}
+			replaced = true
+		}
+
+		if replaced {
+			log.V(4).Info("replacing previously generated item", "resource", id.String())
+		} else {
+			log.Info("pruning previously generated item that is not generated anymore", "resource", id.String())
+		}
+	}
+	return kept, nil
+}
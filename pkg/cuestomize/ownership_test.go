@@ -0,0 +1,85 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+)
+
+func TestProcessOutputs_Ownership(t *testing.T) {
+	unified := cuecontext.New().CompileString(`outputs: cm: {apiVersion: "v1", kind: "ConfigMap", metadata: name: "generated", data: version: "2"}`)
+	require.NoError(t, unified.Err())
+
+	// items as written to disk by a previous run: a stale copy of the output, a resource
+	// that is not generated anymore, and a resource owned by another function config.
+	items := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: generated
+  annotations:
+    config.cuestomize.io/owned-by: my-config
+    internal.config.kubernetes.io/path: generated.yaml
+    internal.config.kubernetes.io/index: '0'
+data:
+  version: "1"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: obsolete
+  annotations:
+    config.cuestomize.io/owned-by: my-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other
+  annotations:
+    config.cuestomize.io/owned-by: other-config
+`
+
+	newConfig := func(enabled bool) *api.KRMInput {
+		return &api.KRMInput{ObjectMeta: metav1.ObjectMeta{Name: "my-config"}, Ownership: &api.Ownership{Enabled: enabled}}
+	}
+
+	t.Run("re-run duplicates the output when disabled", func(t *testing.T) {
+		t.Parallel()
+		nodes, err := kio.FromBytes([]byte(items))
+		require.NoError(t, err)
+
+		_, _, err = ProcessOutputs(t.Context(), unified, nodes, newConfig(false))
+
+		require.Error(t, err)
+	})
+
+	t.Run("re-run replaces and prunes owned resources when enabled", func(t *testing.T) {
+		t.Parallel()
+		nodes, err := kio.FromBytes([]byte(items))
+		require.NoError(t, err)
+
+		result, _, err := ProcessOutputs(t.Context(), unified, nodes, newConfig(true))
+
+		require.NoError(t, err)
+		ids := make([]string, 0, len(result))
+		for _, item := range result {
+			ids = append(ids, resid.FromRNode(item).Name)
+		}
+		require.Equal(t, []string{"other", "generated"}, ids)
+
+		generated := result[1]
+		require.Equal(t, "my-config", generated.GetAnnotations()[api.OwnedByAnnotation])
+		path, index, err := kioutil.GetFileAnnotations(generated)
+		require.NoError(t, err)
+		require.Equal(t, "generated.yaml", path)
+		require.Equal(t, "0", index)
+		version, err := generated.GetString("data.version")
+		require.NoError(t, err)
+		require.Equal(t, "2", version)
+	})
+}
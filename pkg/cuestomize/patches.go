@@ -0,0 +1,61 @@
+package cuestomize
+
+import (
+	"context"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/cuerrors"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ApplyPatches applies the partial objects found at the given patches path in the unified CUE instance onto the items
+// with the same resource ID. Patches are applied with a JSON merge patch if the merge policy is json-merge,
+// and with a strategic merge otherwise.
+// Patches are optional: if the path does not exist in the unified CUE instance, items are returned unchanged.
+func ApplyPatches(ctx context.Context, unified cue.Value, items []*kyaml.RNode, policy api.MergePolicy, patchesPath string) ([]*kyaml.RNode, error) {
+	log := logr.FromContextOrDiscard(ctx)
+	detailer := cuerrors.FromContextOrEmpty(ctx)
+
+	patchesValue := unified.LookupPath(cue.ParsePath(patchesPath))
+	if !patchesValue.Exists() {
+		return items, nil
+	} else if patchesValue.Err() != nil {
+		return nil, detailer.ErrorWithDetails(patchesValue.Err(), "failed to lookup '%s' in unified CUE instance", patchesPath)
+	}
+	patchesIter, err := getIter(patchesValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get iterator over '%s' in unified CUE instance: %v", patchesPath, err)
+	}
+
+	patchPolicy := api.MergePolicyStrategicMerge
+	if policy == api.MergePolicyJSONMerge {
+		patchPolicy = api.MergePolicyJSONMerge
+	}
+
+	for patchesIter.Next() {
+		value := patchesIter.Value()
+
+		patch, err := cueValueToRNode(&value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert CUE value to kyaml.RNode: %w", err)
+		}
+
+		id := resid.FromRNode(patch)
+		index := indexOf(items, id)
+		if index < 0 {
+			return nil, fmt.Errorf("no item matches the patch at '%v' for %s", value.Path(), id)
+		}
+
+		log.V(4).Info("applying patch to item", "policy", patchPolicy,
+			"kind", patch.GetKind(), "apiVersion", patch.GetApiVersion(), "namespace", patch.GetNamespace(), "name", patch.GetName())
+		items[index], err = merge(items[index], patch, patchPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply the patch at '%v' for %s: %w", value.Path(), id, err)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,69 @@
+package cuestomize
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Workday/cuestomize/api"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// PlaceOutputs sets the annotations recording the file each output is written to, and its index in the file.
+// The file is the one set by the model (config.kubernetes.io/path), if any, or else the one rendered from
+// the path template. Outputs without a file are left to the default one, and outputs with the same resource ID
+// as an item keep the file of the item they are merged onto.
+// Indexes follow the ones of the items (and outputs) already placed in each file, in the order of the outputs.
+func PlaceOutputs(outputs []Output, items []*kyaml.RNode, template api.PathTemplate) error {
+	next := make(map[string]int)
+	reserve := func(node *kyaml.RNode) {
+		path, index, _ := kioutil.GetFileAnnotations(node)
+		if path == "" {
+			return
+		}
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			i = 0
+		}
+		if i >= next[path] {
+			next[path] = i + 1
+		}
+	}
+
+	var unplaced []Output
+	for _, item := range items {
+		reserve(item)
+	}
+	for _, output := range outputs {
+		if indexOf(items, resid.FromRNode(output.Node)) >= 0 {
+			continue
+		}
+		if path, index, _ := kioutil.GetFileAnnotations(output.Node); path != "" && index != "" {
+			reserve(output.Node)
+			continue
+		}
+		unplaced = append(unplaced, output)
+	}
+
+	for _, output := range unplaced {
+		path, _, _ := kioutil.GetFileAnnotations(output.Node)
+		if path == "" && template != "" {
+			rendered, err := template.Render(output.Node)
+			if err != nil {
+				return fmt.Errorf("failed to render the path of output at '%v': %w", output.Path, err)
+			}
+			path = rendered
+		}
+		if path == "" {
+			continue
+		}
+
+		index := next[path]
+		next[path]++
+		if err := setLocationAnnotations(output.Node, path, strconv.Itoa(index)); err != nil {
+			return fmt.Errorf("failed to place output at '%v' in '%s': %w", output.Path, path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+)
+
+func TestPlaceOutputs(t *testing.T) {
+	model := `outputs: [
+	{apiVersion: "apps/v1", kind: "Deployment", metadata: {name: "app", namespace: "app"}},
+	{apiVersion: "rbac.authorization.k8s.io/v1", kind: "ClusterRole", metadata: name: "reader"},
+	{apiVersion: "v1", kind: "ConfigMap", metadata: {
+		name:      "extra"
+		namespace: "app"
+		annotations: "config.kubernetes.io/path": "app/config.yaml"
+	}},
+	{apiVersion: "v1", kind: "ConfigMap", metadata: {name: "existing", namespace: "app"}},
+]`
+	items := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: existing
+  namespace: app
+  annotations:
+    internal.config.kubernetes.io/path: app/config.yaml
+    internal.config.kubernetes.io/index: '0'
+`
+
+	tests := []struct {
+		name     string
+		template api.PathTemplate
+		expected [][2]string
+	}{
+		{
+			name:     "no template",
+			template: "",
+			expected: [][2]string{{"", ""}, {"", ""}, {"app/config.yaml", "1"}, {"", ""}},
+		},
+		{
+			name:     "template",
+			template: "{{namespace}}/{{kind}}-{{name}}.yaml",
+			expected: [][2]string{
+				{"app/Deployment-app.yaml", "0"},
+				{"ClusterRole-reader.yaml", "0"},
+				{"app/config.yaml", "1"},
+				{"", ""},
+			},
+		},
+		{
+			name:     "single file",
+			template: "generated.yaml",
+			expected: [][2]string{
+				{"generated.yaml", "0"},
+				{"generated.yaml", "1"},
+				{"app/config.yaml", "1"},
+				{"", ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			unified := cuecontext.New().CompileString(model)
+			require.NoError(t, unified.Err())
+			outputs, err := CollectOutputs(t.Context(), unified, OutputsPath)
+			require.NoError(t, err)
+			nodes, err := kio.FromBytes([]byte(items))
+			require.NoError(t, err)
+
+			err = PlaceOutputs(outputs, nodes, tt.template)
+
+			require.NoError(t, err)
+			locations := make([][2]string, 0, len(outputs))
+			for _, output := range outputs {
+				path, index, err := kioutil.GetFileAnnotations(output.Node)
+				require.NoError(t, err)
+				locations = append(locations, [2]string{path, index})
+			}
+			require.Equal(t, tt.expected, locations)
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package cuestomize
+
+import (
+	"context"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/cuerrors"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// policySelectorField is the field of a policy holding the selector of the items it applies to.
+	policySelectorField = "selector"
+	// policyDefinitionField is the field of a policy holding the definition the matching items must satisfy.
+	policyDefinitionField = "definition"
+	// policySeverityField is the (optional) field of a policy holding the severity of its violations.
+	policySeverityField = "severity"
+	// policyMutationField is the (optional) field of a policy holding how it mutates the matching items.
+	policyMutationField = "mutation"
+
+	// PolicyTag is the tag of the results of policy violations, holding the CUE path of the violated policy.
+	PolicyTag = "policy"
+)
+
+// PolicyMutation defines how a policy mutates the items it matches, with the concrete value resulting
+// from their unification with its definition (e.g. with the defaults of the definition).
+type PolicyMutation string
+
+const (
+	// PolicyMutationFull replaces the items with the concrete value resulting from the unification.
+	PolicyMutationFull PolicyMutation = "full"
+	// PolicyMutationAdditive only adds to the items the fields of the concrete value they do not have.
+	PolicyMutationAdditive PolicyMutation = "additive"
+)
+
+// Policy is a CUE definition that the stream items matched by its selector must satisfy.
+type Policy struct {
+	// Path is the CUE path of the policy.
+	Path cue.Path
+	// Name is the label of the policy in the policies struct (or its index in the policies list),
+	// by which items are exempted from it.
+	Name string
+	// Selector selects the items the policy applies to. An empty selector matches every item.
+	Selector types.Selector
+	// Definition is the CUE value each matching item is unified with.
+	Definition cue.Value
+	// Severity is the severity of the violations of the policy, unless set otherwise by the @severity
+	// attribute of the offending field. Defaults to error.
+	Severity framework.Severity
+	// Mutation is how the policy mutates the items it matches. Policies without mutation only validate them.
+	Mutation PolicyMutation
+}
+
+// CollectPolicies returns the policies found at the given policies path in the unified CUE instance.
+// Each policy has a selector, with the same fields as the include selectors, a definition, and optionally
+// the severity of its violations (error, warning or info) and its mutation (full or additive).
+// Policies are optional: if the path does not exist in the unified CUE instance, no policy is returned.
+func CollectPolicies(ctx context.Context, unified cue.Value, policiesPath string) ([]Policy, error) {
+	detailer := cuerrors.FromContextOrEmpty(ctx)
+
+	policiesValue := unified.LookupPath(cue.ParsePath(policiesPath))
+	if !policiesValue.Exists() {
+		return nil, nil
+	} else if policiesValue.Err() != nil {
+		return nil, detailer.ErrorWithDetails(policiesValue.Err(), "failed to lookup '%s' in unified CUE instance", policiesPath)
+	}
+	policiesIter, err := getIter(policiesValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get iterator over '%s' in unified CUE instance: %v", policiesPath, err)
+	}
+
+	var policies []Policy
+	for policiesIter.Next() {
+		value := policiesIter.Value()
+
+		selectorValue := value.LookupPath(cue.ParsePath(policySelectorField))
+		if !selectorValue.Exists() {
+			return nil, fmt.Errorf("the policy at '%v' has no %s", value.Path(), policySelectorField)
+		}
+		sel, err := decodeSelector(selectorValue)
+		if err != nil {
+			return nil, detailer.ErrorWithDetails(err, "failed to decode the selector of the policy at '%v'", value.Path())
+		}
+		definition := value.LookupPath(cue.ParsePath(policyDefinitionField))
+		if !definition.Exists() {
+			return nil, fmt.Errorf("the policy at '%v' has no %s", value.Path(), policyDefinitionField)
+		}
+
+		severity := framework.Error
+		if severityValue := value.LookupPath(cue.ParsePath(policySeverityField)); severityValue.Exists() {
+			s, err := severityValue.String()
+			if err != nil {
+				return nil, detailer.ErrorWithDetails(err, "failed to decode the severity of the policy at '%v'", value.Path())
+			}
+			switch severity = framework.Severity(s); severity {
+			case framework.Info, framework.Warning, framework.Error:
+			default:
+				return nil, fmt.Errorf(`the policy at '%v' has an unsupported severity "%s", must be one of: %s, %s, %s`,
+					value.Path(), s, framework.Info, framework.Warning, framework.Error)
+			}
+		}
+
+		var mutation PolicyMutation
+		if mutationValue := value.LookupPath(cue.ParsePath(policyMutationField)); mutationValue.Exists() {
+			m, err := mutationValue.String()
+			if err != nil {
+				return nil, detailer.ErrorWithDetails(err, "failed to decode the mutation of the policy at '%v'", value.Path())
+			}
+			switch mutation = PolicyMutation(m); mutation {
+			case PolicyMutationFull, PolicyMutationAdditive:
+			default:
+				return nil, fmt.Errorf(`the policy at '%v' has an unsupported mutation "%s", must be one of: %s, %s`,
+					value.Path(), m, PolicyMutationFull, PolicyMutationAdditive)
+			}
+		}
+
+		selectors := value.Path().Selectors()
+		policies = append(policies, Policy{
+			Path:       value.Path(),
+			Name:       unquote(selectors[len(selectors)-1].String()),
+			Selector:   sel,
+			Definition: definition,
+			Severity:   severity,
+			Mutation:   mutation,
+		})
+	}
+	return policies, nil
+}
+
+// EvaluatePolicies unifies each item with the definition of every policy whose selector matches it, and
+// returns the violations as results, with the severity of the policy unless the offending field sets another one.
+// Items are evaluated independently of each other, so every result refers to the offending item, to its
+// file (if known), and to the offending field in it, and is tagged with the path of the violated policy.
+// Items not violating a mutating policy are mutated with the result of their unification, in the order
+// of the policies, and the mutated items are returned.
+// Items exempted from the function config with the given name, or from a policy, are not evaluated.
+func EvaluatePolicies(ctx context.Context, policies []Policy, items []*kyaml.RNode, configName string) ([]*kyaml.RNode, framework.Results, error) {
+	log := logr.FromContextOrDiscard(ctx)
+	detailer := cuerrors.FromContextOrEmpty(ctx)
+
+	var results framework.Results
+	for _, policy := range policies {
+		matched := 0
+		for i, item := range items {
+			matches, err := api.ItemMatchReference(item, &policy.Selector)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to match item against the policy at '%v' [%v]: %w", policy.Path, policy.Selector.String(), err)
+			}
+			if !matches {
+				continue
+			}
+			exemption, err := api.GetExemption(item)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid exemption: %w", err)
+			}
+			if exemption.Exempts(configName) || exemption.Exempts(policy.Name) {
+				continue
+			}
+			matched++
+
+			itemMap, err := item.Map()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to convert item to map: %w", err)
+			}
+			itemValue := policy.Definition.Context().Encode(itemMap)
+			if itemValue.Err() != nil {
+				return nil, nil, detailer.ErrorWithDetails(itemValue.Err(), "failed to convert item into CUE value")
+			}
+			evaluated := itemValue.Unify(policy.Definition)
+
+			violations := CollectViolations(evaluated)
+			for _, violation := range violations {
+				if violation.Severity == "" {
+					violation.Severity = policy.Severity
+				}
+				result := violationResult(detailer, violation, violation.Path)
+				result.ResourceRef = resourceRef(item)
+				result.File = itemFile(item)
+				setTag(result, PolicyTag, policy.Path.String())
+				results = append(results, result)
+			}
+
+			// items violating the policy are left as they are, as the result of their unification is not concrete
+			if policy.Mutation == "" || len(violations) > 0 {
+				continue
+			}
+			mutated, err := mutate(item, evaluated, policy.Mutation)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to mutate item %s with the policy at '%v': %w", resid.FromRNode(item), policy.Path, err)
+			}
+			log.V(4).Info("mutated item", "path", policy.Path.String(), "mutation", policy.Mutation,
+				"kind", item.GetKind(), "apiVersion", item.GetApiVersion(), "namespace", item.GetNamespace(), "name", item.GetName())
+			items[i] = mutated
+		}
+		log.V(4).Info("evaluated policy", "path", policy.Path.String(), "selector", policy.Selector.String(), "items", matched)
+	}
+	return items, results, nil
+}
+
+// mutate returns the item mutated with the concrete value resulting from its unification with a definition.
+func mutate(item *kyaml.RNode, evaluated cue.Value, mutation PolicyMutation) (*kyaml.RNode, error) {
+	mutated, err := cueValueToRNode(&evaluated)
+	if err != nil {
+		return nil, err
+	}
+	if mutation == PolicyMutationFull {
+		return mutated, nil
+	}
+	if err := addMissingFields(item, mutated); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// addMissingFields adds to the node the fields of the mutated node it does not have, recursively.
+// The elements of lists having as many elements in both nodes are completed one by one.
+func addMissingFields(node, mutated *kyaml.RNode) error {
+	switch {
+	case node.YNode().Kind == kyaml.MappingNode && mutated.YNode().Kind == kyaml.MappingNode:
+		return mutated.VisitFields(func(field *kyaml.MapNode) error {
+			name := field.Key.YNode().Value
+			existing := node.Field(name)
+			if existing == nil {
+				return node.PipeE(kyaml.SetField(name, field.Value))
+			}
+			return addMissingFields(existing.Value, field.Value)
+		})
+	case node.YNode().Kind == kyaml.SequenceNode && mutated.YNode().Kind == kyaml.SequenceNode:
+		elements, mutatedElements := node.YNode().Content, mutated.YNode().Content
+		if len(elements) != len(mutatedElements) {
+			return nil
+		}
+		for i := range elements {
+			if err := addMissingFields(kyaml.NewRNode(elements[i]), kyaml.NewRNode(mutatedElements[i])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resourceRef returns the identifier of the item.
+func resourceRef(item *kyaml.RNode) *kyaml.ResourceIdentifier {
+	return &kyaml.ResourceIdentifier{
+		TypeMeta: kyaml.TypeMeta{APIVersion: item.GetApiVersion(), Kind: item.GetKind()},
+		NameMeta: kyaml.NameMeta{Namespace: item.GetNamespace(), Name: item.GetName()},
+	}
+}
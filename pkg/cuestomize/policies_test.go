@@ -0,0 +1,329 @@
+package cuestomize
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestEvaluatePolicies(t *testing.T) {
+	items := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: app
+  annotations:
+    config.kubernetes.io/path: deployments.yaml
+    config.kubernetes.io/index: '1'
+spec:
+  replicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: other
+  namespace: app
+  labels:
+    team: platform
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: app
+`
+
+	tests := []struct {
+		name           string
+		model          string
+		expected       framework.Results
+		errorSubstring string
+	}{
+		{
+			name:  "no policies",
+			model: `outputs: []`,
+		},
+		{
+			name: "every matching item is evaluated independently",
+			model: `
+#Deployment: {
+	metadata: {
+		labels: {team!: string, ...}
+		...
+	}
+	spec: {replicas: >=2, ...}
+	...
+}
+#policies: deployments: {
+	selector: {group: "apps", kind: "Deployment"}
+	definition: #Deployment
+}`,
+			expected: framework.Results{
+				{
+					Message:     "field is required but not present",
+					Severity:    framework.Error,
+					ResourceRef: &kyaml.ResourceIdentifier{TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}, NameMeta: kyaml.NameMeta{Name: "app", Namespace: "app"}},
+					Field:       &framework.Field{Path: "metadata.labels.team"},
+					File:        &framework.File{Path: "deployments.yaml", Index: 1},
+					Tags:        map[string]string{PolicyTag: "#policies.deployments", CUEPositionTag: "main.cue:4:12"},
+				},
+				{
+					Message:     "invalid value 1 (out of bound >=2)",
+					Severity:    framework.Error,
+					ResourceRef: &kyaml.ResourceIdentifier{TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}, NameMeta: kyaml.NameMeta{Name: "app", Namespace: "app"}},
+					Field:       &framework.Field{Path: "spec.replicas"},
+					File:        &framework.File{Path: "deployments.yaml", Index: 1},
+					Tags:        map[string]string{PolicyTag: "#policies.deployments", CUEPositionTag: "main.cue:7:19"},
+				},
+			},
+		},
+		{
+			name: "empty selector matches every item",
+			model: `
+#Namespaced: {
+	metadata: {namespace: "default", ...}
+	...
+}
+#policies: [{
+	selector: {}
+	definition: #Namespaced
+}]`,
+			expected: framework.Results{
+				{
+					Message:     `conflicting values "default" and "app"`,
+					Severity:    framework.Error,
+					ResourceRef: &kyaml.ResourceIdentifier{TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}, NameMeta: kyaml.NameMeta{Name: "app", Namespace: "app"}},
+					Field:       &framework.Field{Path: "metadata.namespace"},
+					File:        &framework.File{Path: "deployments.yaml", Index: 1},
+					Tags:        map[string]string{PolicyTag: "#policies[0]"},
+				},
+				{
+					Message:     `conflicting values "default" and "app"`,
+					Severity:    framework.Error,
+					ResourceRef: &kyaml.ResourceIdentifier{TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}, NameMeta: kyaml.NameMeta{Name: "other", Namespace: "app"}},
+					Field:       &framework.Field{Path: "metadata.namespace"},
+					Tags:        map[string]string{PolicyTag: "#policies[0]"},
+				},
+				{
+					Message:     `conflicting values "default" and "app"`,
+					Severity:    framework.Error,
+					ResourceRef: &kyaml.ResourceIdentifier{TypeMeta: kyaml.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}, NameMeta: kyaml.NameMeta{Name: "config", Namespace: "app"}},
+					Field:       &framework.Field{Path: "metadata.namespace"},
+					Tags:        map[string]string{PolicyTag: "#policies[0]"},
+				},
+			},
+		},
+		{
+			name: "severity of the policy, unless set by the offending field",
+			model: `
+#policies: configmaps: {
+	selector: kind: "ConfigMap"
+	severity: "warning"
+	definition: {
+		metadata: {
+			labels!: _ @severity(info)
+			namespace: "default"
+			...
+		}
+		...
+	}
+}`,
+			expected: framework.Results{
+				{
+					Message:     "field is required but not present",
+					Severity:    framework.Info,
+					ResourceRef: &kyaml.ResourceIdentifier{TypeMeta: kyaml.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}, NameMeta: kyaml.NameMeta{Name: "config", Namespace: "app"}},
+					Field:       &framework.Field{Path: "metadata.labels"},
+					Tags:        map[string]string{PolicyTag: "#policies.configmaps", CUEPositionTag: "main.cue:7:4"},
+				},
+				{
+					Message:     `conflicting values "default" and "app"`,
+					Severity:    framework.Warning,
+					ResourceRef: &kyaml.ResourceIdentifier{TypeMeta: kyaml.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}, NameMeta: kyaml.NameMeta{Name: "config", Namespace: "app"}},
+					Field:       &framework.Field{Path: "metadata.namespace"},
+					Tags:        map[string]string{PolicyTag: "#policies.configmaps"},
+				},
+			},
+		},
+		{
+			name: "policy with unsupported severity",
+			model: `#policies: configmaps: {
+	selector: kind: "ConfigMap"
+	severity: "fatal"
+	definition: {...}
+}`,
+			errorSubstring: `the policy at '#policies.configmaps' has an unsupported severity "fatal"`,
+		},
+		{
+			name: "policy with resource-shaped selector",
+			model: `#policies: deployments: {
+	selector: {kind: "Deployment", metadata: name: "app"}
+	definition: {...}
+}`,
+			errorSubstring: `failed to decode the selector of the policy at '#policies.deployments': invalid selector: json: unknown field "metadata"`,
+		},
+		{
+			name:           "policy without definition",
+			model:          `#policies: deployments: selector: kind: "Deployment"`,
+			errorSubstring: "the policy at '#policies.deployments' has no definition",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			unified := cuecontext.New().CompileString(tt.model, cue.Filename("main.cue"))
+			require.NoError(t, unified.Err())
+			nodes, err := kio.FromBytes([]byte(items))
+			require.NoError(t, err)
+
+			policies, err := CollectPolicies(t.Context(), unified, PoliciesPath)
+
+			if tt.errorSubstring != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+			_, results, err := EvaluatePolicies(t.Context(), policies, nodes, "")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, results)
+		})
+	}
+}
+
+func TestEvaluatePolicies_Mutation(t *testing.T) {
+	items := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1 # pinned
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: other
+  namespace: app
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: other
+        image: other:v1
+`
+	model := `
+#Deployment: {
+	metadata: {
+		labels: {team: *"platform" | string, ...}
+		...
+	}
+	spec: {
+		replicas: *2 | >=2
+		template: spec: {
+			containers: [...{
+				securityContext: runAsNonRoot: *true | bool
+				...
+			}]
+			...
+		}
+		...
+	}
+	...
+}
+#policies: deployments: {
+	selector: kind: "Deployment"
+	mutation:   %s
+	definition: #Deployment
+}`
+
+	tests := []struct {
+		name            string
+		mutation        PolicyMutation
+		expected        string
+		expectedResults int
+	}{
+		{
+			name:     "additive mutation only adds the defaulted fields",
+			mutation: PolicyMutationAdditive,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: app
+  labels:
+    team: platform
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1 # pinned
+        securityContext:
+          runAsNonRoot: true
+  replicas: 2
+`,
+			expectedResults: 3,
+		},
+		{
+			name:     "full mutation replaces the item",
+			mutation: PolicyMutationFull,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    team: platform
+  name: app
+  namespace: app
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - image: app:v1
+        name: app
+        securityContext:
+          runAsNonRoot: true
+`,
+			expectedResults: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			unified := cuecontext.New().CompileString(fmt.Sprintf(model, strconv.Quote(string(tt.mutation))))
+			require.NoError(t, unified.Err())
+			nodes, err := kio.FromBytes([]byte(items))
+			require.NoError(t, err)
+			violating := nodes[1].MustString()
+
+			policies, err := CollectPolicies(t.Context(), unified, PoliciesPath)
+			require.NoError(t, err)
+
+			mutated, results, err := EvaluatePolicies(t.Context(), policies, nodes, "")
+
+			require.NoError(t, err)
+			require.Len(t, mutated, 2)
+			require.Equal(t, tt.expected, mutated[0].MustString())
+			// items violating the policy are not mutated
+			require.Len(t, results, tt.expectedResults)
+			require.Equal(t, violating, mutated[1].MustString())
+		})
+	}
+}
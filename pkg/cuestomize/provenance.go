@@ -0,0 +1,39 @@
+package cuestomize
+
+import (
+	"fmt"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/cuestomize/model"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// StampProvenance annotates the outputs with the name of the function config, the source of the CUE model
+// and the CUE path that produced them, as configured by the provenance configuration.
+// Pieces of information that are unknown (e.g. the digest of a local model) are not stamped.
+func StampProvenance(outputs []Output, config *api.KRMInput, source model.Source) error {
+	if config.Provenance == nil || !config.Provenance.Enabled {
+		return nil
+	}
+
+	for _, output := range outputs {
+		values := [][2]string{
+			{api.ProvenanceConfigName, config.Name},
+			{api.ProvenanceSource, source.Location},
+			{api.ProvenanceTag, source.Tag},
+			{api.ProvenanceDigest, source.Digest},
+			{api.ProvenancePath, output.Path.String()},
+		}
+		for _, v := range values {
+			info, value := v[0], v[1]
+			key := config.Provenance.AnnotationKey(info)
+			if key == "" || value == "" {
+				continue
+			}
+			if err := output.Node.PipeE(kyaml.SetAnnotation(key, value)); err != nil {
+				return fmt.Errorf("failed to set provenance annotation '%s' on output at '%v': %w", key, output.Path, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/cuestomize/model"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProcessOutputs_Provenance(t *testing.T) {
+	unified := cuecontext.New().CompileString(`outputs: cm: {apiVersion: "v1", kind: "ConfigMap", metadata: name: "generated"}`)
+	require.NoError(t, unified.Err())
+
+	tests := []struct {
+		name       string
+		provenance *api.Provenance
+		expected   map[string]string
+	}{
+		{
+			name:     "disabled by default",
+			expected: map[string]string{},
+		},
+		{
+			name:       "default annotations",
+			provenance: &api.Provenance{Enabled: true},
+			expected: map[string]string{
+				"config.cuestomize.io/config-name":   "my-config",
+				"config.cuestomize.io/module-source": "/cue-resources",
+				"config.cuestomize.io/cue-path":      "outputs.cm",
+			},
+		},
+		{
+			name: "custom and suppressed annotations",
+			provenance: &api.Provenance{Enabled: true, Annotations: map[string]string{
+				api.ProvenanceConfigName: "example.com/generated-by",
+				api.ProvenanceSource:     "",
+			}},
+			expected: map[string]string{
+				"example.com/generated-by":      "my-config",
+				"config.cuestomize.io/cue-path": "outputs.cm",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			config := &api.KRMInput{ObjectMeta: metav1.ObjectMeta{Name: "my-config"}, Provenance: tt.provenance}
+
+			result, _, err := ProcessOutputs(t.Context(), unified, nil, config,
+				WithModelProvider(model.NewLocalPathProvider("/cue-resources")))
+
+			require.NoError(t, err)
+			require.Len(t, result, 1)
+			require.Equal(t, tt.expected, result[0].GetAnnotations())
+		})
+	}
+}
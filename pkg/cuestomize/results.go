@@ -0,0 +1,71 @@
+package cuestomize
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"github.com/Workday/cuestomize/pkg/cuerrors"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+)
+
+// CollectResults collects the function results found at the given results path in the unified CUE instance.
+// Each result has the fields of a ResourceList result: message (required), severity (info, warning or error,
+// info by default), resourceRef, field, file and tags.
+// Results are optional: if the path does not exist in the unified CUE instance, no result is returned.
+func CollectResults(ctx context.Context, unified cue.Value, resultsPath string) (framework.Results, error) {
+	detailer := cuerrors.FromContextOrEmpty(ctx)
+
+	resultsValue := unified.LookupPath(cue.ParsePath(resultsPath))
+	if !resultsValue.Exists() {
+		return nil, nil
+	} else if resultsValue.Err() != nil {
+		return nil, detailer.ErrorWithDetails(resultsValue.Err(), "failed to lookup '%s' in unified CUE instance", resultsPath)
+	}
+	resultsIter, err := getIter(resultsValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get iterator over '%s' in unified CUE instance: %v", resultsPath, err)
+	}
+
+	var results framework.Results
+	for resultsIter.Next() {
+		value := resultsIter.Value()
+
+		result, err := decodeResult(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid result at '%v': %w", value.Path(), err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// decodeResult decodes a CUE value into a function result, rejecting unknown fields and severities.
+func decodeResult(value cue.Value) (*framework.Result, error) {
+	data, err := value.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	result := &framework.Result{}
+	if err := decoder.Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+
+	if result.Message == "" {
+		return nil, fmt.Errorf("message is required")
+	}
+	switch result.Severity {
+	case "":
+		result.Severity = framework.Info
+	case framework.Info, framework.Warning, framework.Error:
+	default:
+		return nil, fmt.Errorf(`unsupported severity "%s", must be one of: %s, %s, %s`,
+			result.Severity, framework.Info, framework.Warning, framework.Error)
+	}
+	return result, nil
+}
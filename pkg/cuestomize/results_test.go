@@ -0,0 +1,83 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestCollectResults(t *testing.T) {
+	tests := []struct {
+		name           string
+		model          string
+		expected       framework.Results
+		errorSubstring string
+	}{
+		{
+			name:  "no results",
+			model: `outputs: []`,
+		},
+		{
+			name: "results with resource and field references",
+			model: `results: [{
+	message:  "field spec.foo is deprecated, use spec.bar"
+	severity: "warning"
+	resourceRef: {apiVersion: "apps/v1", kind: "Deployment", name: "app", namespace: "default"}
+	field: path: "spec.foo"
+}, {
+	message: "generated 3 resources"
+}]`,
+			expected: framework.Results{
+				{
+					Message:  "field spec.foo is deprecated, use spec.bar",
+					Severity: framework.Warning,
+					ResourceRef: &kyaml.ResourceIdentifier{
+						TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+						NameMeta: kyaml.NameMeta{Name: "app", Namespace: "default"},
+					},
+					Field: &framework.Field{Path: "spec.foo"},
+				},
+				{
+					Message:  "generated 3 resources",
+					Severity: framework.Info,
+				},
+			},
+		},
+		{
+			name:           "missing message",
+			model:          `results: noMessage: severity: "error"`,
+			errorSubstring: "invalid result at 'results.noMessage': message is required",
+		},
+		{
+			name:           "unsupported severity",
+			model:          `results: [{message: "oops", severity: "fatal"}]`,
+			errorSubstring: `unsupported severity "fatal"`,
+		},
+		{
+			name:           "unknown field",
+			model:          `results: [{message: "oops", resource: name: "app"}]`,
+			errorSubstring: `unknown field "resource"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			unified := cuecontext.New().CompileString(tt.model)
+			require.NoError(t, unified.Err())
+
+			results, err := CollectResults(t.Context(), unified, ResultsPath)
+
+			if tt.errorSubstring != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errorSubstring)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, results)
+		})
+	}
+}
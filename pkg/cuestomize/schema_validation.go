@@ -0,0 +1,102 @@
+package cuestomize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/kubeschema"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// crdGroup is the API group of CustomResourceDefinitions.
+const crdGroup = "apiextensions.k8s.io"
+
+// ValidateOutputs validates the outputs against the Kubernetes OpenAPI schemas of kubeschema.DefaultVersion,
+// extended with the schemas of the CustomResourceDefinitions found in the items and in the outputs.
+// Every invalid output is reported, with the JSON paths of its violations.
+// Fields not declared in a schema are violations, unless the config reports the ones not declared in a
+// bundled schema, which may have been added by a newer Kubernetes version, as warning results.
+// Outputs whose kind has no known schema are not validated.
+func ValidateOutputs(ctx context.Context, outputs []Output, items []*kyaml.RNode, config *api.SchemaValidation) (framework.Results, error) {
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+	log := logr.FromContextOrDiscard(ctx)
+
+	validator, err := kubeschema.New(kubeschema.DefaultVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Kubernetes schemas: %w", err)
+	}
+
+	crds := make([]*kyaml.RNode, 0)
+	for _, item := range items {
+		if isCRD(item) {
+			crds = append(crds, item)
+		}
+	}
+	for _, output := range outputs {
+		if isCRD(output.Node) {
+			crds = append(crds, output.Node)
+		}
+	}
+	for _, crd := range crds {
+		if err := validator.AddCRD(crd); err != nil {
+			return nil, fmt.Errorf("failed to add schema of CustomResourceDefinition: %w", err)
+		}
+	}
+
+	var results framework.Results
+	var invalid []string
+	for _, output := range outputs {
+		id := resid.FromRNode(output.Node)
+		if !validator.HasSchema(id.Gvk) {
+			log.V(4).Info("no schema found for output, skipping validation", "path", output.Path.String(), "resource", id.String())
+			continue
+		}
+
+		violations, unknownFields, err := validator.Validate(output.Node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate output at '%v': %w", output.Path, err)
+		}
+		if config.UnknownFields == api.UnknownFieldsWarn {
+			for _, unknown := range unknownFields {
+				field, _ := kubeschema.UnknownField(unknown)
+				results = append(results, &framework.Result{
+					Message:     fmt.Sprintf("field is not declared in the schema of Kubernetes %s, it may have been added by a newer version", kubeschema.DefaultVersion),
+					Severity:    framework.Warning,
+					ResourceRef: resourceRef(output.Node),
+					Field:       &framework.Field{Path: field},
+				})
+			}
+		} else {
+			violations = append(violations, unknownFields...)
+			kubeschema.SortErrors(violations)
+		}
+		if len(violations) == 0 {
+			continue
+		}
+
+		var report strings.Builder
+		fmt.Fprintf(&report, "%s (at '%v'):", id, output.Path)
+		for _, violation := range violations {
+			fmt.Fprintf(&report, "\n  - %s", violation)
+		}
+		invalid = append(invalid, report.String())
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("outputs are not valid Kubernetes resources:\n%s", strings.Join(invalid, "\n"))
+	}
+	return results, nil
+}
+
+// isCRD tells whether the node is a CustomResourceDefinition.
+func isCRD(node *kyaml.RNode) bool {
+	gvk := resid.GvkFromNode(node)
+	return gvk.Group == crdGroup && gvk.Kind == "CustomResourceDefinition"
+}
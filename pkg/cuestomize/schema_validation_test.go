@@ -0,0 +1,115 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/Workday/cuestomize/api"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestValidateOutputs(t *testing.T) {
+	tests := []struct {
+		name          string
+		unknownFields api.UnknownFieldsPolicy
+		output        string
+		expected      framework.Results
+		err           string
+	}{
+		{
+			name: "misspelled fields in an open struct fail the validation",
+			output: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  selector:
+    matchLabels: {app: app}
+  template:
+    metadata:
+      labels: {app: app}
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+        imagePulPolicy: Always
+        ports:
+        - containerPort: 8080
+          protocl: TCP
+`,
+			err: `Deployment.v1.apps/app.[noNs] (at 'outputs.pod'):
+  - spec.template.spec.containers[0].imagePulPolicy in body is a forbidden property
+  - spec.template.spec.containers[0].ports[0].protocl in body is a forbidden property`,
+		},
+		{
+			name: "field newer than the bundled schema fails the validation by default",
+			output: `apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  os:
+    name: linux
+  containers:
+  - name: app
+    image: app:v1
+`,
+			err: "spec.os in body is a forbidden property",
+		},
+		{
+			name:          "field newer than the bundled schema is a warning when opted in",
+			unknownFields: api.UnknownFieldsWarn,
+			output: `apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  os:
+    name: linux
+  containers:
+  - name: app
+    image: app:v1
+`,
+			expected: framework.Results{{
+				Message:  "field is not declared in the schema of Kubernetes v1.21.2, it may have been added by a newer version",
+				Severity: framework.Warning,
+				ResourceRef: &kyaml.ResourceIdentifier{
+					TypeMeta: kyaml.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+					NameMeta: kyaml.NameMeta{Name: "app"},
+				},
+				Field: &framework.Field{Path: "spec.os"},
+			}},
+		},
+		{
+			name: "violation fails the validation",
+			output: `apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+  - image: app:v1
+`,
+			err: "spec.containers[0].name in body is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			outputs := []Output{{Path: cue.ParsePath("outputs.pod"), Node: kyaml.MustParse(tt.output)}}
+			config := &api.SchemaValidation{Enabled: true, UnknownFields: tt.unknownFields}
+
+			results, err := ValidateOutputs(t.Context(), outputs, nil, config)
+
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, results)
+		})
+	}
+}
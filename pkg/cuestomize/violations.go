@@ -0,0 +1,405 @@
+package cuestomize
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"github.com/Workday/cuestomize/pkg/cuerrors"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// requiredFieldMessage is the message of the violations of required fields that are not present.
+	requiredFieldMessage = "field is required but not present"
+	// CUEPositionTag is the tag of the results of violations, holding the position of the violated constraint
+	// in the CUE model (e.g. main.cue:12:5).
+	CUEPositionTag = "cue-position"
+	// severityAttribute is the attribute with which the model sets the severity of the violations of a field,
+	// and of the fields below it, e.g. @severity(warning).
+	severityAttribute = "severity"
+)
+
+// Violation is an error found in the unified CUE instance.
+type Violation struct {
+	// Path is the path of the offending value, as a list of CUE labels (e.g. "spec", "replicas").
+	Path []string
+	// Message is the error message, without the path.
+	Message string
+	// Severity is the severity set by the model with the @severity attribute, if any.
+	Severity framework.Severity
+	// Position is the position of the violated constraint in the CUE model, if known.
+	Position token.Pos
+}
+
+// CollectViolations returns every error of the unified CUE instance, including non-concrete values and
+// required fields that are not present, in the order of the fields.
+// The severity of a violation is the one set by the @severity attribute of the offending field, or of
+// the closest of its parents having one.
+//
+// Validating a CUE value stops reporting incomplete values as soon as it finds a conflict, so every
+// value is validated on its own, and the whole instance is validated last to catch the remaining errors.
+func CollectViolations(unified cue.Value) []Violation {
+	collector := violationCollector{root: unified, seen: make(map[string]bool)}
+	collector.walk(unified)
+	collector.add(unified.Validate(cue.Final(), cue.Concrete(true)))
+	return collector.violations
+}
+
+// violationCollector accumulates the violations found while walking a CUE value, without duplicates.
+type violationCollector struct {
+	root       cue.Value
+	violations []Violation
+	seen       map[string]bool
+}
+
+// walk collects the violations of the value and of its fields.
+// Values containing errors are bottom, so structs and lists are told apart by their incomplete kind.
+func (c *violationCollector) walk(value cue.Value) {
+	if value.IncompleteKind() == cue.ListKind {
+		elements, err := value.List()
+		if err != nil {
+			c.add(value.Validate(cue.Final(), cue.Concrete(true)))
+			return
+		}
+		for elements.Next() {
+			c.walk(elements.Value())
+		}
+		return
+	}
+	iter, err := value.Fields(cue.Optional(true))
+	if err != nil {
+		c.add(value.Validate(cue.Final(), cue.Concrete(true)))
+		return
+	}
+	for iter.Next() {
+		switch iter.Selector().ConstraintType() {
+		case cue.OptionalConstraint:
+			continue
+		case cue.RequiredConstraint:
+			// a required field that is not present has no value to lookup, but it has the attributes of its declaration
+			path := labels(iter.Value().Path())
+			severity := attributeSeverity(iter.Value())
+			if severity == "" {
+				severity = c.severity(path[:len(path)-1])
+			}
+			c.append(Violation{Path: path, Message: requiredFieldMessage, Severity: severity, Position: iter.Value().Pos()})
+			continue
+		}
+		c.walk(iter.Value())
+	}
+}
+
+// add collects the errors of err.
+func (c *violationCollector) add(err error) {
+	for _, e := range errors.Errors(err) {
+		format, args := e.Msg()
+		c.append(Violation{Path: e.Path(), Message: fmt.Sprintf(format, args...), Severity: c.severity(e.Path()), Position: e.Position()})
+	}
+}
+
+// severity returns the severity set by the @severity attribute of the value at the given path,
+// or of the closest of its parents having one, or an empty severity if none has.
+func (c *violationCollector) severity(path []string) framework.Severity {
+	for n := len(path); n > 0; n-- {
+		value := c.root.LookupPath(cue.ParsePath(cuePath(path[:n])))
+		if severity := attributeSeverity(value); severity != "" {
+			return severity
+		}
+	}
+	return ""
+}
+
+// attributeSeverity returns the severity set by the @severity attribute of the value, or an empty severity
+// if the value has no such attribute, or if it is not a severity.
+func attributeSeverity(value cue.Value) framework.Severity {
+	attr := value.Attribute(severityAttribute)
+	if attr.Err() != nil {
+		return ""
+	}
+	severity, err := attr.String(0)
+	if err != nil {
+		return ""
+	}
+	switch framework.Severity(severity) {
+	case framework.Error, framework.Warning, framework.Info:
+		return framework.Severity(severity)
+	default:
+		return ""
+	}
+}
+
+// append collects the violation, unless it was already collected.
+func (c *violationCollector) append(violation Violation) {
+	key := strings.Join(violation.Path, "\x00") + "\x00" + violation.Message
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.violations = append(c.violations, violation)
+}
+
+// labels returns the labels of a CUE path, in the format of the paths of CUE errors.
+func labels(path cue.Path) []string {
+	selectors := path.Selectors()
+	labels := make([]string, 0, len(selectors))
+	for _, sel := range selectors {
+		labels = append(labels, sel.String())
+	}
+	return labels
+}
+
+// ViolationResults converts the violations into function results, with error severity unless the model set another one.
+// Violations of an included resource (i.e. under the given includes path) refer to that resource,
+// to its file (if known), and to the offending field in it. Violations of values derived from an included
+// resource (e.g. deployments: includes["apps/v1"]["Deployment"]["default"]) refer to it too, and are reported
+// once per resource, field and CUE position. Other violations refer to their CUE path.
+// Sensitive values are redacted from the messages if the Detailer of the context redacts them.
+func ViolationResults(ctx context.Context, unified cue.Value, violations []Violation, items []*kyaml.RNode, includesPath string) framework.Results {
+	detailer := cuerrors.FromContextOrEmpty(ctx)
+	resolver := includeResolver{root: unified, includesLabels: labels(cue.ParsePath(includesPath)), items: items}
+
+	results := make(framework.Results, 0, len(violations))
+	seen := make(map[string]bool)
+	for _, violation := range violations {
+		fieldLabels := violation.Path
+		ref, includedLabels := resolver.resolve(violation.Path, 0)
+		if ref != nil {
+			fieldLabels = includedLabels
+		}
+
+		result := violationResult(detailer, violation, fieldLabels)
+		if ref != nil {
+			result.ResourceRef = ref
+			result.File = includedFile(items, ref)
+		}
+		if key := resultKey(result); !seen[key] {
+			seen[key] = true
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// resultKey identifies the violated constraint of a result: its resource, field and CUE position.
+// The message is left out, as CUE reports the same conflict with the values in either order
+// (e.g. "conflicting values int and "3"" and "conflicting values "3" and int") depending on the path.
+func resultKey(result *framework.Result) string {
+	var key strings.Builder
+	if result.ResourceRef != nil {
+		fmt.Fprintf(&key, "%s/%s/%s/%s", result.ResourceRef.APIVersion, result.ResourceRef.Kind,
+			result.ResourceRef.Namespace, result.ResourceRef.Name)
+	}
+	key.WriteString("\x00")
+	if result.Field != nil {
+		key.WriteString(result.Field.Path)
+	}
+	key.WriteString("\x00" + result.Tags[CUEPositionTag])
+	return key.String()
+}
+
+// includeResolver resolves the values of the unified CUE instance back to the included resources they derive from.
+type includeResolver struct {
+	root           cue.Value
+	includesLabels []string
+	items          []*kyaml.RNode
+}
+
+// resolve returns the included resource the value at the given path derives from, and the labels of the field
+// in it, or nil if the value does not derive from an included resource.
+// Includes are filled at includes.<apiVersion>.<kind>.<namespace>.<name>: other values are resolved by
+// following the references of the value, or of its parents, e.g. deployments.app.spec through
+// deployments: includes["apps/v1"]["Deployment"]["default"], or by matching the identity of a parent
+// (apiVersion, kind, metadata.namespace and metadata.name) with the one of an item.
+func (r *includeResolver) resolve(path []string, depth int) (*kyaml.ResourceIdentifier, []string) {
+	if len(path) >= len(r.includesLabels)+4 && hasPrefix(path, r.includesLabels) {
+		labels := path[len(r.includesLabels):]
+		return &kyaml.ResourceIdentifier{
+			TypeMeta: kyaml.TypeMeta{APIVersion: unquote(labels[0]), Kind: unquote(labels[1])},
+			NameMeta: kyaml.NameMeta{Namespace: unquote(labels[2]), Name: unquote(labels[3])},
+		}, labels[4:]
+	}
+	if depth > maxReferenceDepth {
+		return nil, nil
+	}
+
+	for n := len(path); n > 0; n-- {
+		value := r.root.LookupPath(cue.ParsePath(cuePath(path[:n])))
+		for _, reference := range references(value) {
+			if ref, fieldLabels := r.resolve(append(labels(reference), path[n:]...), depth+1); ref != nil {
+				return ref, fieldLabels
+			}
+		}
+		if ref := r.identity(value); ref != nil {
+			return ref, path[n:]
+		}
+	}
+	return nil, nil
+}
+
+// identity returns the identifier of the item whose identity the value has, or nil if there is none.
+func (r *includeResolver) identity(value cue.Value) *kyaml.ResourceIdentifier {
+	fields := make([]string, 4)
+	for i, field := range []string{"apiVersion", "kind", "metadata.namespace", "metadata.name"} {
+		fields[i], _ = value.LookupPath(cue.ParsePath(field)).String()
+	}
+	if fields[1] == "" || fields[3] == "" {
+		return nil
+	}
+	ref := &kyaml.ResourceIdentifier{
+		TypeMeta: kyaml.TypeMeta{APIVersion: fields[0], Kind: fields[1]},
+		NameMeta: kyaml.NameMeta{Namespace: fields[2], Name: fields[3]},
+	}
+	group, version := resid.ParseGroupVersion(ref.APIVersion)
+	if indexOf(r.items, resid.NewResIdWithNamespace(resid.Gvk{Group: group, Version: version, Kind: ref.Kind}, ref.Name, ref.Namespace)) < 0 {
+		return nil
+	}
+	return ref
+}
+
+// references returns the paths the value refers to: its reference, or the references it is unified with.
+func references(value cue.Value) []cue.Path {
+	if _, ref := value.ReferencePath(); len(ref.Selectors()) > 0 {
+		return []cue.Path{ref}
+	}
+	op, args := value.Expr()
+	if op != cue.AndOp {
+		return nil
+	}
+	var refs []cue.Path
+	for _, arg := range args {
+		if _, ref := arg.ReferencePath(); len(ref.Selectors()) > 0 {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// violationResult returns a result for the violation of the field with the given labels.
+// Its severity is the one of the violation, or error if the violation has none.
+func violationResult(detailer cuerrors.Detailer, violation Violation, fieldLabels []string) *framework.Result {
+	result := &framework.Result{
+		Message:  cuerrors.Redact(detailer, violation.Message),
+		Severity: violation.Severity,
+	}
+	if result.Severity == "" {
+		result.Severity = framework.Error
+	}
+	if len(fieldLabels) > 0 {
+		result.Field = &framework.Field{Path: fieldPath(fieldLabels)}
+	}
+	if violation.Position.IsValid() {
+		setTag(result, CUEPositionTag, violation.Position.String())
+	}
+	return result
+}
+
+// setTag sets a tag of the result.
+func setTag(result *framework.Result, key, value string) {
+	if result.Tags == nil {
+		result.Tags = make(map[string]string)
+	}
+	result.Tags[key] = value
+}
+
+// RelativePositions makes the CUE positions of the results relative to the directory of the CUE model,
+// if they are in it.
+func RelativePositions(results framework.Results, modelPath string) {
+	absModelPath, err := filepath.Abs(modelPath)
+	if err != nil {
+		return
+	}
+	for _, result := range results {
+		position, ok := result.Tags[CUEPositionTag]
+		if !ok {
+			continue
+		}
+		// positions are relative to the working directory when the model was loaded from a relative path
+		position, err := filepath.Abs(position)
+		if err != nil {
+			continue
+		}
+		if relative, err := filepath.Rel(absModelPath, position); err == nil && !strings.HasPrefix(relative, "..") {
+			result.Tags[CUEPositionTag] = relative
+		}
+	}
+}
+
+// includedFile returns the file of the item with the given identifier, or nil if it is not known.
+func includedFile(items []*kyaml.RNode, ref *kyaml.ResourceIdentifier) *framework.File {
+	group, version := resid.ParseGroupVersion(ref.APIVersion)
+	id := resid.NewResIdWithNamespace(resid.Gvk{Group: group, Version: version, Kind: ref.Kind}, ref.Name, ref.Namespace)
+	index := indexOf(items, id)
+	if index < 0 {
+		return nil
+	}
+	return itemFile(items[index])
+}
+
+// itemFile returns the file of the item, or nil if it is not known.
+func itemFile(item *kyaml.RNode) *framework.File {
+	path, index, _ := kioutil.GetFileAnnotations(item)
+	if path == "" {
+		return nil
+	}
+	i, _ := strconv.Atoi(index)
+	return &framework.File{Path: path, Index: i}
+}
+
+// fieldPath formats CUE labels as a field path, e.g. spec.containers[0].image.
+func fieldPath(labels []string) string {
+	var path strings.Builder
+	for _, label := range labels {
+		if _, err := strconv.Atoi(label); err == nil {
+			fmt.Fprintf(&path, "[%s]", label)
+			continue
+		}
+		if path.Len() > 0 {
+			path.WriteString(".")
+		}
+		path.WriteString(unquote(label))
+	}
+	return path.String()
+}
+
+// cuePath formats CUE labels as a CUE path, e.g. spec.containers[0].image.
+func cuePath(labels []string) string {
+	var path strings.Builder
+	for _, label := range labels {
+		if _, err := strconv.Atoi(label); err == nil {
+			fmt.Fprintf(&path, "[%s]", label)
+			continue
+		}
+		if path.Len() > 0 {
+			path.WriteString(".")
+		}
+		path.WriteString(label)
+	}
+	return path.String()
+}
+
+// hasPrefix tells whether the labels start with the given prefix.
+func hasPrefix(labels, prefix []string) bool {
+	for i := range prefix {
+		if labels[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// unquote returns the label without the quotes CUE adds to labels that are not identifiers.
+func unquote(label string) string {
+	if unquoted, err := strconv.Unquote(label); err == nil {
+		return unquoted
+	}
+	return label
+}
@@ -0,0 +1,253 @@
+package cuestomize
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestCollectViolations(t *testing.T) {
+	model := `
+#Deployment: {
+	metadata: labels: team!: string
+	spec: replicas:            >=2 @severity(warning)
+	spec: template: spec: containers: [...{image: =~":v[0-9]+$"}]
+}
+includes: "apps/v1": Deployment: [string]: [string]: #Deployment
+includes: "apps/v1": Deployment: default: {
+	a: {
+		metadata: labels: {}
+		spec: replicas: 1
+		spec: template: spec: containers: [{image: "app:latest"}]
+	}
+	b: {
+		metadata: labels: team: "x"
+		spec: replicas: 3
+		spec: template: spec: containers: [{image: "app:v1"}]
+	}
+}
+threshold: int
+`
+	items := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: a
+  namespace: default
+  annotations:
+    internal.config.kubernetes.io/path: deployments.yaml
+    internal.config.kubernetes.io/index: '1'
+`
+
+	unified := cuecontext.New().CompileString(model, cue.Filename("/model/main.cue"))
+	nodes, err := kio.FromBytes([]byte(items))
+	require.NoError(t, err)
+
+	violations := CollectViolations(unified)
+	results := ViolationResults(t.Context(), unified, violations, nodes, IncludesFillPath)
+	RelativePositions(results, "/model")
+
+	deploymentA := &kyaml.ResourceIdentifier{
+		TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		NameMeta: kyaml.NameMeta{Name: "a", Namespace: "default"},
+	}
+	file := &framework.File{Path: "deployments.yaml", Index: 1}
+	expected := framework.Results{
+		{
+			Message:     "field is required but not present",
+			Severity:    framework.Error,
+			ResourceRef: deploymentA,
+			File:        file,
+			Field:       &framework.Field{Path: "metadata.labels.team"},
+			Tags:        map[string]string{CUEPositionTag: "main.cue:3:20"},
+		},
+		{
+			Message:     "invalid value 1 (out of bound >=2)",
+			Severity:    framework.Warning,
+			ResourceRef: deploymentA,
+			File:        file,
+			Field:       &framework.Field{Path: "spec.replicas"},
+			Tags:        map[string]string{CUEPositionTag: "main.cue:4:29"},
+		},
+		{
+			Message:     `invalid value "app:latest" (out of bound =~":v[0-9]+$")`,
+			Severity:    framework.Error,
+			ResourceRef: deploymentA,
+			File:        file,
+			Field:       &framework.Field{Path: "spec.template.spec.containers[0].image"},
+			Tags:        map[string]string{CUEPositionTag: "main.cue:5:48"},
+		},
+		{
+			Message:  "incomplete value int",
+			Severity: framework.Error,
+			Field:    &framework.Field{Path: "threshold"},
+		},
+	}
+	require.Equal(t, expected, results)
+}
+
+func TestViolationResults_Aliases(t *testing.T) {
+	// the aliasing pattern of examples/validation
+	model := `
+includes: _
+
+deployments: includes["apps/v1"]["Deployment"]["example-namespace"]
+
+#Labels: [string]: string
+
+#Deployment: {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata!: {
+		name!:   =~"example-.*"
+		labels!: #Labels
+		...
+	}
+	spec!: {
+		replicas!: int & >0 & <5
+		...
+	}
+}
+
+deployments: [string]: #Deployment
+
+#ValidateSelectorsOverMap: {
+	map: [string]: _
+	validated: {
+		for name, deploy in map {
+			"deployment<\(name)>": d: deploy
+		}
+	}
+}
+
+validated: #ValidateSelectorsOverMap & {map: deployments}
+`
+	items := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-deployment
+  namespace: example-namespace
+  labels:
+    app: example-app
+  annotations:
+    internal.config.kubernetes.io/path: deployment.yaml
+    internal.config.kubernetes.io/index: '0'
+spec:
+  replicas: 7
+`
+
+	cueCtx := cuecontext.New()
+	nodes, err := kio.FromBytes([]byte(items))
+	require.NoError(t, err)
+	includes := cueCtx.CompileString(`"apps/v1": Deployment: "example-namespace": "my-deployment": {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata: {name: "my-deployment", namespace: "example-namespace", labels: app: "example-app"}
+	spec: replicas: 7
+}`)
+	unified := cueCtx.CompileString(model, cue.Filename("/model/main.cue")).FillPath(cue.ParsePath(IncludesFillPath), includes)
+
+	results := ViolationResults(t.Context(), unified, CollectViolations(unified), nodes, IncludesFillPath)
+	RelativePositions(results, "/model")
+
+	deployment := &kyaml.ResourceIdentifier{
+		TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		NameMeta: kyaml.NameMeta{Name: "my-deployment", Namespace: "example-namespace"},
+	}
+	file := &framework.File{Path: "deployment.yaml"}
+	// violations found through deployments, validated.map and validated.validated are reported once
+	expected := framework.Results{
+		{
+			Message:     `invalid value "my-deployment" (out of bound =~"example-.*")`,
+			Severity:    framework.Error,
+			ResourceRef: deployment,
+			File:        file,
+			Field:       &framework.Field{Path: "metadata.name"},
+			Tags:        map[string]string{CUEPositionTag: "main.cue:12:12"},
+		},
+		{
+			Message:     "invalid value 7 (out of bound <5)",
+			Severity:    framework.Error,
+			ResourceRef: deployment,
+			File:        file,
+			Field:       &framework.Field{Path: "spec.replicas"},
+			Tags:        map[string]string{CUEPositionTag: "main.cue:17:25"},
+		},
+	}
+	require.Equal(t, expected, results)
+}
+
+func TestViolationResults_SwappedConflicts(t *testing.T) {
+	// CUE reports the conflict of the replicas with the values in either order, depending on the path
+	model := `
+includes: _
+deployments: includes["apps/v1"]["Deployment"]["default"]
+deployments: [string]: spec: replicas: int
+checked: spec: replicas: int & includes["apps/v1"]["Deployment"]["default"].a.spec.replicas
+`
+	items := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: a
+  namespace: default
+`
+
+	cueCtx := cuecontext.New()
+	nodes, err := kio.FromBytes([]byte(items))
+	require.NoError(t, err)
+	includes := cueCtx.CompileString(`"apps/v1": Deployment: default: a: {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata: {name: "a", namespace: "default"}
+	spec: replicas: "3"
+}`)
+	unified := cueCtx.CompileString(model).FillPath(cue.ParsePath(IncludesFillPath), includes)
+
+	results := ViolationResults(t.Context(), unified, CollectViolations(unified), nodes, IncludesFillPath)
+
+	require.Len(t, results, 1)
+	require.Equal(t, "spec.replicas", results[0].Field.Path)
+}
+
+func TestRelativePositions(t *testing.T) {
+	tests := []struct {
+		name      string
+		modelPath string
+		position  string
+		expected  string
+	}{
+		{
+			name:      "absolute position in absolute model path",
+			modelPath: "/model",
+			position:  "/model/main.cue:3:20",
+			expected:  "main.cue:3:20",
+		},
+		{
+			name:      "relative position in relative model path",
+			modelPath: "model",
+			position:  "model/main.cue:3:20",
+			expected:  "main.cue:3:20",
+		},
+		{
+			name:      "position outside of the model",
+			modelPath: "/model",
+			position:  "/other/main.cue:3:20",
+			expected:  "/other/main.cue:3:20",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			results := framework.Results{{Tags: map[string]string{CUEPositionTag: tt.position}}}
+			RelativePositions(results, tt.modelPath)
+
+			require.Equal(t, tt.expected, results[0].Tags[CUEPositionTag])
+		})
+	}
+}
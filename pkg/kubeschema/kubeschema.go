@@ -0,0 +1,294 @@
+// Package kubeschema validates Kubernetes resources against the OpenAPI schemas bundled with kustomize,
+// extended with the schemas of CustomResourceDefinitions, without contacting any cluster.
+package kubeschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	openapi_v2 "github.com/google/gnostic-models/openapiv2"
+	"google.golang.org/protobuf/proto"
+	openapierrors "k8s.io/kube-openapi/pkg/validation/errors"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+	"sigs.k8s.io/kustomize/kyaml/openapi/kubernetesapi"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// gvkExtension is the extension listing the group, version and kind of a definition.
+	gvkExtension = "x-kubernetes-group-version-kind"
+	// preserveUnknownFieldsExtension is the extension allowing fields not declared in a schema.
+	preserveUnknownFieldsExtension = "x-kubernetes-preserve-unknown-fields"
+	// intOrStringExtension is the extension allowing either an integer or a string.
+	intOrStringExtension = "x-kubernetes-int-or-string"
+	// intOrStringFormat is the format of the definitions allowing either an integer or a string.
+	intOrStringFormat = "int-or-string"
+
+	// definitionsPrefix is the prefix of the references to the definitions of the schema.
+	definitionsPrefix = "#/definitions/"
+	// objectMetaDefinition is the definition of the metadata of every object.
+	objectMetaDefinition = "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"
+	// quantityDefinition is the definition of resource quantities, which are accepted as numbers too.
+	quantityDefinition = "io.k8s.apimachinery.pkg.api.resource.Quantity"
+)
+
+// DefaultVersion is the Kubernetes version whose schemas are used when New is given none.
+const DefaultVersion = kubernetesapi.DefaultOpenAPI
+
+// Versions returns the Kubernetes versions whose schemas are bundled, sorted.
+// Only the versions bundled with kustomize are available, currently v1.21.2.
+func Versions() []string {
+	versions := make([]string, 0, len(kubernetesapi.OpenAPIMustAsset))
+	for v := range kubernetesapi.OpenAPIMustAsset {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Validator validates Kubernetes resources against their OpenAPI schema.
+//
+// Schemas are validated strictly: fields not declared in a schema are reported, unless the schema
+// preserves unknown fields. As the bundled schemas may be older than the cluster, fields not declared in
+// them are returned apart from the other violations, so that callers can choose how to report them,
+// while the ones not declared in the schema of a CustomResourceDefinition are always violations. Resources whose kind has no known schema are not validated.
+type Validator struct {
+	definitions spec.Definitions
+	byGVK       map[resid.Gvk]*spec.Schema
+	// fromCRD holds the kinds whose schema is declared by a CustomResourceDefinition.
+	fromCRD map[resid.Gvk]bool
+}
+
+// New creates a Validator using the bundled schemas of the given Kubernetes version.
+// An empty version selects DefaultVersion.
+func New(version string) (*Validator, error) {
+	if version == "" {
+		version = DefaultVersion
+	}
+	asset, ok := kubernetesapi.OpenAPIMustAsset[version]
+	if !ok {
+		return nil, fmt.Errorf("schemas of Kubernetes version '%s' are not bundled, must be one of: %v", version, Versions())
+	}
+
+	doc := &openapi_v2.Document{}
+	assetName := path.Join("kubernetesapi", strings.ReplaceAll(version, ".", "_"), "swagger.pb")
+	if err := proto.Unmarshal(asset(assetName), doc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal schemas of Kubernetes version '%s': %w", version, err)
+	}
+	var swagger spec.Swagger
+	if _, err := swagger.FromGnostic(doc); err != nil {
+		return nil, fmt.Errorf("failed to convert schemas of Kubernetes version '%s': %w", version, err)
+	}
+
+	v := &Validator{
+		definitions: swagger.Definitions,
+		byGVK:       make(map[resid.Gvk]*spec.Schema),
+		fromCRD:     make(map[resid.Gvk]bool),
+	}
+	for name := range v.definitions {
+		schema := v.definitions[name]
+		var gvks []struct {
+			Group   string `json:"group"`
+			Version string `json:"version"`
+			Kind    string `json:"kind"`
+		}
+		if err := schema.Extensions.GetObject(gvkExtension, &gvks); err != nil {
+			return nil, fmt.Errorf("failed to read the kinds of definition '%s': %w", name, err)
+		}
+		for _, gvk := range gvks {
+			v.byGVK[resid.Gvk{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}] = &schema
+		}
+	}
+	return v, nil
+}
+
+// AddCRD adds the schemas of the versions of a CustomResourceDefinition to the validator.
+// Versions without a schema are skipped.
+func (v *Validator) AddCRD(crd *kyaml.RNode) error {
+	group, err := crd.GetString("spec.group")
+	if err != nil {
+		return fmt.Errorf("CustomResourceDefinition '%s' has no group: %w", crd.GetName(), err)
+	}
+	kind, err := crd.GetString("spec.names.kind")
+	if err != nil {
+		return fmt.Errorf("CustomResourceDefinition '%s' has no kind: %w", crd.GetName(), err)
+	}
+
+	// apiextensions.k8s.io/v1beta1 may declare a single schema for all the versions
+	sharedSchema, err := crd.Pipe(kyaml.Lookup("spec", "validation", "openAPIV3Schema"))
+	if err != nil {
+		return fmt.Errorf("failed to read the schema of CustomResourceDefinition '%s': %w", crd.GetName(), err)
+	}
+
+	versions, err := crd.Pipe(kyaml.Lookup("spec", "versions"))
+	if err != nil || versions == nil {
+		return fmt.Errorf("CustomResourceDefinition '%s' has no versions", crd.GetName())
+	}
+	elements, err := versions.Elements()
+	if err != nil {
+		return fmt.Errorf("failed to read the versions of CustomResourceDefinition '%s': %w", crd.GetName(), err)
+	}
+	for _, version := range elements {
+		name, err := version.GetString("name")
+		if err != nil {
+			return fmt.Errorf("CustomResourceDefinition '%s' has a version without name: %w", crd.GetName(), err)
+		}
+		schemaNode, err := version.Pipe(kyaml.Lookup("schema", "openAPIV3Schema"))
+		if err != nil {
+			return fmt.Errorf("failed to read the schema of version '%s' of CustomResourceDefinition '%s': %w", name, crd.GetName(), err)
+		}
+		if schemaNode == nil {
+			schemaNode = sharedSchema
+		}
+		if schemaNode == nil {
+			continue
+		}
+
+		schema, err := toSchema(schemaNode)
+		if err != nil {
+			return fmt.Errorf("invalid schema of version '%s' of CustomResourceDefinition '%s': %w", name, crd.GetName(), err)
+		}
+		gvk := resid.Gvk{Group: group, Version: name, Kind: kind}
+		v.byGVK[gvk] = schema
+		v.fromCRD[gvk] = true
+	}
+	return nil
+}
+
+// HasSchema tells whether the validator knows the schema of the given kind.
+func (v *Validator) HasSchema(gvk resid.Gvk) bool {
+	_, ok := v.byGVK[gvk]
+	return ok
+}
+
+// Validate validates a resource against the schema of its kind, and returns the violations, sorted.
+// Every violation names the JSON path of the offending field.
+// Fields not declared in a bundled schema, which may have been added by a newer Kubernetes version,
+// are returned apart as unknown fields (sorted, see UnknownField) rather than as violations.
+// Resources whose kind has no known schema have no violations.
+func (v *Validator) Validate(node *kyaml.RNode) ([]error, []error, error) {
+	gvk := resid.GvkFromNode(node)
+	schema, ok := v.byGVK[gvk]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	// round-trip through JSON, so that numbers have the types the validator expects
+	data, err := node.MarshalJSON()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal resource: %w", err)
+	}
+
+	result := v.newValidator(schema, "").Validate(document)
+	var violations, unknownFields []error
+	for _, violation := range result.Errors {
+		if _, ok := UnknownField(violation); ok && !v.fromCRD[gvk] {
+			unknownFields = append(unknownFields, violation)
+			continue
+		}
+		violations = append(violations, violation)
+	}
+	SortErrors(violations)
+	SortErrors(unknownFields)
+	return violations, unknownFields, nil
+}
+
+// UnknownField returns the JSON path of the field a violation reports as not declared in the schema,
+// if it is one.
+func UnknownField(violation error) (string, bool) {
+	var validation *openapierrors.Validation
+	if !errors.As(violation, &validation) || validation.Code() != openapierrors.UnallowedPropertyCode {
+		return "", false
+	}
+	key := fmt.Sprint(validation.Value)
+	if validation.Name == "" {
+		return key, true
+	}
+	return validation.Name + "." + key, true
+}
+
+// SortErrors sorts errors by their message.
+func SortErrors(errs []error) {
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+}
+
+// newValidator creates a schema validator that resolves the references of the schema lazily,
+// as the validator of kube-openapi does not support them.
+func (v *Validator) newValidator(schema *spec.Schema, path string) *validate.SchemaValidator {
+	option := func(opts *validate.SchemaValidatorOptions) {
+		opts.NewValidatorForField = func(_ string, schema *spec.Schema, _ interface{}, path string, _ strfmt.Registry, _ ...validate.Option) validate.ValueValidator {
+			return v.newValidator(schema, path)
+		}
+		opts.NewValidatorForIndex = func(_ int, schema *spec.Schema, _ interface{}, path string, _ strfmt.Registry, _ ...validate.Option) validate.ValueValidator {
+			return v.newValidator(schema, path)
+		}
+	}
+	return validate.NewSchemaValidator(v.normalize(schema), nil, path, strfmt.Default, option)
+}
+
+// normalize resolves the reference of the schema, if any, and returns a copy of it adapted to how
+// the API server validates resources: objects declaring properties are closed, and int-or-string
+// values and quantities accept both integers and strings.
+func (v *Validator) normalize(schema *spec.Schema) *spec.Schema {
+	name := ""
+	for ref := schema.Ref.String(); ref != ""; ref = schema.Ref.String() {
+		name = strings.TrimPrefix(ref, definitionsPrefix)
+		definition, ok := v.definitions[name]
+		if !ok {
+			// an unknown reference accepts anything, rather than failing the validation
+			return &spec.Schema{}
+		}
+		schema = &definition
+	}
+
+	normalized := *schema
+	if preserve, _ := normalized.Extensions.GetBool(preserveUnknownFieldsExtension); preserve {
+		return &normalized
+	}
+	intOrString, _ := normalized.Extensions.GetBool(intOrStringExtension)
+	if intOrString || normalized.Format == intOrStringFormat || name == quantityDefinition {
+		normalized.Type = nil
+		normalized.Format = ""
+	}
+	if len(normalized.Properties) > 0 && normalized.AdditionalProperties == nil {
+		normalized.AdditionalProperties = &spec.SchemaOrBool{Allows: false}
+	}
+	return &normalized
+}
+
+// toSchema converts the openAPIV3Schema of a CustomResourceDefinition into a schema, declaring the
+// fields every object has, which CustomResourceDefinitions usually leave out.
+func toSchema(node *kyaml.RNode) (*spec.Schema, error) {
+	data, err := node.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	schema := &spec.Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, err
+	}
+
+	if schema.Properties == nil {
+		schema.Properties = make(map[string]spec.Schema)
+	}
+	for _, field := range []string{"apiVersion", "kind"} {
+		if _, ok := schema.Properties[field]; !ok {
+			schema.Properties[field] = *spec.StringProperty()
+		}
+	}
+	if metadata, ok := schema.Properties["metadata"]; !ok || len(metadata.Properties) == 0 {
+		schema.Properties["metadata"] = *spec.RefSchema(definitionsPrefix + objectMetaDefinition)
+	}
+	return schema, nil
+}
@@ -0,0 +1,195 @@
+package kubeschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const testCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                type: integer
+                minimum: 1
+              port:
+                x-kubernetes-int-or-string: true
+              extra:
+                type: object
+                x-kubernetes-preserve-unknown-fields: true
+`
+
+func TestValidator_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		resource      string
+		violations    []string
+		unknownFields []string
+	}{
+		{
+			name: "valid deployment",
+			resource: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  selector:
+    matchLabels: {app: app}
+  template:
+    metadata:
+      labels: {app: app}
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+        ports:
+        - containerPort: 8080
+        resources:
+          limits: {cpu: 1, memory: 1Gi}
+        livenessProbe:
+          httpGet: {port: http, path: /healthz}
+`,
+		},
+		{
+			name: "misspelled field in a container",
+			resource: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  selector:
+    matchLabels: {app: app}
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+        ports:
+        - contianerPort: 8080
+`,
+			violations:    []string{"spec.template.spec.containers[0].ports[0].containerPort in body is required"},
+			unknownFields: []string{"spec.template.spec.containers[0].ports[0].contianerPort"},
+		},
+		{
+			name: "field newer than the bundled schema",
+			resource: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  selector:
+    matchLabels: {app: app}
+  template:
+    metadata:
+      labels: {app: app}
+    spec:
+      os:
+        name: linux
+      containers:
+      - name: app
+        image: app:v1
+`,
+			unknownFields: []string{"spec.template.spec.os"},
+		},
+		{
+			name: "wrong type",
+			resource: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  labels:
+    app: 1
+`,
+			violations: []string{`metadata.labels.app in body must be of type string: "number"`},
+		},
+		{
+			name: "valid custom resource",
+			resource: `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+spec:
+  size: 3
+  port: http
+  extra:
+    anything: goes
+`,
+		},
+		{
+			name: "invalid custom resource",
+			resource: `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: widget
+  lables: {}
+spec:
+  size: 0
+`,
+			violations: []string{
+				"metadata.lables in body is a forbidden property",
+				"spec.size in body should be greater than or equal to 1",
+			},
+		},
+		{
+			name: "unknown kind",
+			resource: `apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: gadget
+anything: goes
+`,
+		},
+	}
+
+	validator, err := New("")
+	require.NoError(t, err)
+	require.NoError(t, validator.AddCRD(kyaml.MustParse(testCRD)))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, unknownFields, err := validator.Validate(kyaml.MustParse(tt.resource))
+			require.NoError(t, err)
+			var fields []string
+			for _, u := range unknownFields {
+				field, ok := UnknownField(u)
+				require.True(t, ok, "not an unknown field: %v", u)
+				fields = append(fields, field)
+			}
+			require.Equal(t, tt.unknownFields, fields)
+
+			messages := make([]string, 0, len(violations))
+			for _, v := range violations {
+				messages = append(messages, v.Error())
+			}
+			require.Equal(t, len(tt.violations), len(messages), "violations: %v", messages)
+			for i := range tt.violations {
+				require.Equal(t, tt.violations[i], messages[i])
+			}
+		})
+	}
+}
+
+func TestNew_UnsupportedVersion(t *testing.T) {
+	_, err := New("v0.1.0")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "are not bundled")
+}
@@ -6,23 +6,25 @@ import (
 	"fmt"
 
 	"github.com/go-logr/logr"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content/file"
 	"oras.land/oras-go/v2/registry/remote"
 )
 
 // FetchFromOCIRegistry fetches an artifact from an OCI registry and stores it in the specified working directory.
-func FetchFromOCIRegistry(ctx context.Context, client remote.Client, workingDir, reg, repo, tag string, plainHTTP bool) error {
+// It returns the descriptor of the fetched artifact.
+func FetchFromOCIRegistry(ctx context.Context, client remote.Client, workingDir, reg, repo, tag string, plainHTTP bool) (ocispec.Descriptor, error) {
 	log := logr.FromContextOrDiscard(ctx).V(4)
 
 	fs, err := file.New(workingDir)
 	if err != nil {
-		return fmt.Errorf("failed to create file store: %w", err)
+		return ocispec.Descriptor{}, fmt.Errorf("failed to create file store: %w", err)
 	}
 
 	repository, err := remote.NewRepository(reg + "/" + repo)
 	if err != nil {
-		return err
+		return ocispec.Descriptor{}, err
 	}
 	if client != nil {
 		repository.Client = client
@@ -31,7 +33,7 @@ func FetchFromOCIRegistry(ctx context.Context, client remote.Client, workingDir,
 
 	desc, err := oras.Copy(ctx, repository, tag, fs, tag, oras.DefaultCopyOptions)
 	if err != nil {
-		return err
+		return ocispec.Descriptor{}, err
 	}
 
 	log.Info("fetched artifact from OCI registry",
@@ -42,5 +44,5 @@ func FetchFromOCIRegistry(ctx context.Context, client remote.Client, workingDir,
 		"mediaType", desc.MediaType,
 	)
 
-	return nil
+	return desc, nil
 }
@@ -77,7 +77,7 @@ func Test_FetchFromRegistry(t *testing.T) {
 			_ = testhelpers.PushDirectoryToOCIRegistryT(t, tc.registryHost+"/"+tc.repo+":"+tc.tag, tc.testdataDir, tc.artifactType, tc.tag, tc.client, tc.plainHTTP)
 
 			// Fetch the module from the registry
-			err := FetchFromOCIRegistry(ctx, tc.client, tempDir, tc.registryHost, tc.repo, tc.tag, tc.plainHTTP)
+			_, err := FetchFromOCIRegistry(ctx, tc.client, tempDir, tc.registryHost, tc.repo, tc.tag, tc.plainHTTP)
 			if !tc.shouldError {
 				require.NoError(t, err, "failed to fetch module from OCI registry")
 				// verify that tempDir contains the expected files
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Workday/cuestomize/pkg/cuestomize"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// modelTestCase is the name of the test case of the results that do not refer to a resource.
+const modelTestCase = "model"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// encodeJUnit writes the results as a JUnit report, with a test suite named after the function config and
+// a test case for each item, failing if the item has error results. Warning and info results of an item
+// are written to its standard output.
+// Results that do not refer to an item are reported in an additional test case for the model.
+func encodeJUnit(w io.Writer, name string, results framework.Results, items []*kyaml.RNode) error {
+	if name == "" {
+		name = "cuestomize"
+	}
+	suite := junitTestSuite{Name: name}
+
+	reported := make([]bool, len(results))
+	for _, item := range items {
+		ref := &kyaml.ResourceIdentifier{
+			TypeMeta: kyaml.TypeMeta{APIVersion: item.GetApiVersion(), Kind: item.GetKind()},
+			NameMeta: kyaml.NameMeta{Namespace: item.GetNamespace(), Name: item.GetName()},
+		}
+		var itemResults framework.Results
+		for i, result := range results {
+			if result.ResourceRef != nil && *result.ResourceRef == *ref {
+				itemResults = append(itemResults, result)
+				reported[i] = true
+			}
+		}
+		suite.TestCases = append(suite.TestCases, junitTestCaseOf(resourceName(ref), name, itemResults))
+	}
+
+	var modelResults framework.Results
+	for i, result := range results {
+		if !reported[i] {
+			modelResults = append(modelResults, result)
+		}
+	}
+	if len(modelResults) > 0 {
+		suite.TestCases = append(suite.TestCases, junitTestCaseOf(modelTestCase, name, modelResults))
+	}
+
+	suite.Tests = len(suite.TestCases)
+	for _, testCase := range suite.TestCases {
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(junitTestSuites{Name: name, Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitTestCaseOf returns the test case with the given results, failing if any of them is an error.
+func junitTestCaseOf(name, className string, results framework.Results) junitTestCase {
+	testCase := junitTestCase{Name: name, ClassName: className}
+
+	var failures, output []string
+	for _, result := range results {
+		if result.Severity == framework.Error || result.Severity == "" {
+			failures = append(failures, junitLine(result))
+		} else {
+			output = append(output, fmt.Sprintf("[%s] %s", result.Severity, junitLine(result)))
+		}
+	}
+	if len(failures) > 0 {
+		testCase.Failure = &junitFailure{
+			Message: fmt.Sprintf("%d constraint(s) violated", len(failures)),
+			Type:    string(framework.Error),
+			Text:    strings.Join(failures, "\n"),
+		}
+	}
+	testCase.SystemOut = strings.Join(output, "\n")
+	return testCase
+}
+
+// junitLine formats a result as a line of a test case, with the offending field and the position of the
+// violated constraint, if known.
+func junitLine(result *framework.Result) string {
+	var line strings.Builder
+	if result.Field != nil && result.Field.Path != "" {
+		line.WriteString(result.Field.Path + ": ")
+	}
+	line.WriteString(result.Message)
+	if position, ok := result.Tags[cuestomize.CUEPositionTag]; ok {
+		fmt.Fprintf(&line, " (%s)", position)
+	}
+	return line.String()
+}
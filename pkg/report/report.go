@@ -0,0 +1,140 @@
+// Package report writes the results of validation runs in formats consumed by CI tools,
+// such as SARIF for code scanning and JUnit XML for test reports.
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// SARIFReportAnnotation is the annotation of the function config holding the destination of the SARIF report.
+	SARIFReportAnnotation = "config.cuestomize.io/sarif-report"
+	// JUnitReportAnnotation is the annotation of the function config holding the destination of the JUnit report.
+	JUnitReportAnnotation = "config.cuestomize.io/junit-report"
+	// SARIFReportEnvVar is the environment variable holding the destination of the SARIF report,
+	// unless the function config sets it through its annotation.
+	SARIFReportEnvVar = "SARIF_REPORT"
+	// JUnitReportEnvVar is the environment variable holding the destination of the JUnit report,
+	// unless the function config sets it through its annotation.
+	JUnitReportEnvVar = "JUNIT_REPORT"
+
+	// StderrDestination is the destination writing a report to the standard error.
+	StderrDestination = "stderr"
+)
+
+// Format is the format of a report.
+type Format string
+
+const (
+	// FormatSARIF is the SARIF 2.1.0 format.
+	FormatSARIF Format = "sarif"
+	// FormatJUnit is the JUnit XML format.
+	FormatJUnit Format = "junit"
+)
+
+// Destinations returns the destination of each report configured for the function config, either through
+// its annotations or, if it has none, through environment variables.
+// A destination is either the path of a file (e.g. on a mounted volume) or stderr.
+func Destinations(config *api.KRMInput) map[Format]string {
+	destinations := make(map[Format]string)
+	for format, keys := range map[Format][2]string{
+		FormatSARIF: {SARIFReportAnnotation, SARIFReportEnvVar},
+		FormatJUnit: {JUnitReportAnnotation, JUnitReportEnvVar},
+	} {
+		destination := strings.TrimSpace(config.Annotations[keys[0]])
+		if destination == "" {
+			destination = strings.TrimSpace(os.Getenv(keys[1]))
+		}
+		if destination != "" {
+			destinations[format] = destination
+		}
+	}
+	return destinations
+}
+
+// Write writes the results of the run of the function config, about the given items, in every report
+// configured for the function config.
+func Write(ctx context.Context, config *api.KRMInput, results framework.Results, items []*kyaml.RNode) error {
+	log := logr.FromContextOrDiscard(ctx)
+
+	for format, destination := range Destinations(config) {
+		if err := WriteTo(destination, format, config.Name, results, items); err != nil {
+			return fmt.Errorf("failed to write %s report to '%s': %w", format, destination, err)
+		}
+		log.V(4).Info("wrote report", "format", format, "destination", destination, "results", len(results))
+	}
+	return nil
+}
+
+// WriteTo writes the report in the given format to the destination, which is either the path of a file or stderr.
+func WriteTo(destination string, format Format, name string, results framework.Results, items []*kyaml.RNode) error {
+	if destination == StderrDestination {
+		return Encode(os.Stderr, format, name, results, items)
+	}
+
+	f, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	if err := Encode(f, format, name, results, items); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Encode writes the report in the given format to w.
+// The name is the name of the function config the results are from.
+func Encode(w io.Writer, format Format, name string, results framework.Results, items []*kyaml.RNode) error {
+	switch format {
+	case FormatSARIF:
+		return encodeSARIF(w, results)
+	case FormatJUnit:
+		return encodeJUnit(w, name, results, items)
+	default:
+		return fmt.Errorf("unsupported report format '%s', must be one of: %s, %s", format, FormatSARIF, FormatJUnit)
+	}
+}
+
+// resourceName returns the identity of a resource, e.g. apps/v1/Deployment/namespace/name.
+func resourceName(ref *kyaml.ResourceIdentifier) string {
+	parts := []string{ref.APIVersion, ref.Kind}
+	if ref.Namespace != "" {
+		parts = append(parts, ref.Namespace)
+	}
+	return strings.Join(append(parts, ref.Name), "/")
+}
+
+// position is a position in a file, e.g. main.cue:12:5.
+type position struct {
+	file   string
+	line   int
+	column int
+}
+
+// parsePosition parses a position in the format file:line:column, returning false if it is not one.
+func parsePosition(value string) (position, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 3 {
+		return position{}, false
+	}
+	line, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return position{}, false
+	}
+	column, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return position{}, false
+	}
+	return position{file: strings.Join(parts[:len(parts)-2], ":"), line: line, column: column}, true
+}
@@ -0,0 +1,210 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Workday/cuestomize/api"
+	"github.com/Workday/cuestomize/pkg/cuestomize"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const items = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: app
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: app
+`
+
+var (
+	deploymentRef = &kyaml.ResourceIdentifier{
+		TypeMeta: kyaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		NameMeta: kyaml.NameMeta{Namespace: "app", Name: "app"},
+	}
+	results = framework.Results{
+		{
+			Message:     "invalid value 1 (out of bound >=2)",
+			Severity:    framework.Error,
+			ResourceRef: deploymentRef,
+			Field:       &framework.Field{Path: "spec.replicas"},
+			File:        &framework.File{Path: "deployments.yaml"},
+			Tags:        map[string]string{cuestomize.PolicyTag: "#policies.deployments", cuestomize.CUEPositionTag: "main.cue:7:19"},
+		},
+		{
+			Message:     "field is required but not present",
+			Severity:    framework.Warning,
+			ResourceRef: deploymentRef,
+			Field:       &framework.Field{Path: "metadata.labels.team"},
+			Tags:        map[string]string{cuestomize.CUEPositionTag: "main.cue:4:12"},
+		},
+		{
+			Message:  "incomplete value int",
+			Severity: framework.Error,
+			Field:    &framework.Field{Path: "threshold"},
+			Tags:     map[string]string{cuestomize.CUEPositionTag: "main.cue:9:13"},
+		},
+	}
+)
+
+func TestEncode_SARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, FormatSARIF, "validator", results, nil))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	require.Equal(t, sarifVersion, log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	require.Equal(t, []sarifRule{{ID: "#policies.deployments"}, {ID: constraintRuleID}}, run.Tool.Driver.Rules)
+	require.Equal(t, []sarifResult{
+		{
+			RuleID:  "#policies.deployments",
+			Level:   "error",
+			Message: sarifMessage{Text: "invalid value 1 (out of bound >=2)"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: "deployments.yaml"}},
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "apps/v1/Deployment/app/app", Kind: "resource"}},
+			}},
+			RelatedLocations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "main.cue"},
+					Region:           &sarifRegion{StartLine: 7, StartColumn: 19},
+				},
+				Message: &sarifMessage{Text: "violated constraint"},
+			}},
+			Properties: map[string]string{"field": "spec.replicas"},
+		},
+		{
+			RuleID:  constraintRuleID,
+			Level:   "warning",
+			Message: sarifMessage{Text: "field is required but not present"},
+			Locations: []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "apps/v1/Deployment/app/app", Kind: "resource"}},
+			}},
+			RelatedLocations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "main.cue"},
+					Region:           &sarifRegion{StartLine: 4, StartColumn: 12},
+				},
+				Message: &sarifMessage{Text: "violated constraint"},
+			}},
+			Properties: map[string]string{"field": "metadata.labels.team"},
+		},
+		{
+			RuleID:  constraintRuleID,
+			Level:   "error",
+			Message: sarifMessage{Text: "incomplete value int"},
+			Locations: []sarifLocation{{
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: "main.cue"},
+					Region:           &sarifRegion{StartLine: 9, StartColumn: 13},
+				},
+			}},
+			Properties: map[string]string{"field": "threshold"},
+		},
+	}, run.Results)
+}
+
+func TestEncode_JUnit(t *testing.T) {
+	nodes, err := kio.FromBytes([]byte(items))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, FormatJUnit, "validator", results, nodes))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	require.Equal(t, 3, suites.Tests)
+	require.Equal(t, 2, suites.Failures)
+	require.Len(t, suites.Suites, 1)
+	require.Equal(t, []junitTestCase{
+		{
+			Name:      "apps/v1/Deployment/app/app",
+			ClassName: "validator",
+			Failure: &junitFailure{
+				Message: "1 constraint(s) violated",
+				Type:    "error",
+				Text:    "spec.replicas: invalid value 1 (out of bound >=2) (main.cue:7:19)",
+			},
+			SystemOut: "[warning] metadata.labels.team: field is required but not present (main.cue:4:12)",
+		},
+		{
+			Name:      "v1/ConfigMap/app/config",
+			ClassName: "validator",
+		},
+		{
+			Name:      modelTestCase,
+			ClassName: "validator",
+			Failure: &junitFailure{
+				Message: "1 constraint(s) violated",
+				Type:    "error",
+				Text:    "threshold: incomplete value int (main.cue:9:13)",
+			},
+		},
+	}, suites.Suites[0].TestCases)
+}
+
+func TestEncode_UnsupportedFormat(t *testing.T) {
+	err := Encode(&bytes.Buffer{}, "html", "validator", results, nil)
+	require.ErrorContains(t, err, "unsupported report format 'html'")
+}
+
+func TestDestinations(t *testing.T) {
+	t.Setenv(SARIFReportEnvVar, "/reports/env.sarif")
+	t.Setenv(JUnitReportEnvVar, "/reports/env.xml")
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    map[Format]string
+	}{
+		{
+			name:     "environment variables",
+			expected: map[Format]string{FormatSARIF: "/reports/env.sarif", FormatJUnit: "/reports/env.xml"},
+		},
+		{
+			name:        "annotations take precedence over environment variables",
+			annotations: map[string]string{SARIFReportAnnotation: StderrDestination},
+			expected:    map[Format]string{FormatSARIF: StderrDestination, FormatJUnit: "/reports/env.xml"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &api.KRMInput{}
+			config.Annotations = tt.annotations
+			require.Equal(t, tt.expected, Destinations(config))
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	config := &api.KRMInput{}
+	config.Name = "validator"
+	config.Annotations = map[string]string{
+		SARIFReportAnnotation: filepath.Join(dir, "report.sarif"),
+		JUnitReportAnnotation: filepath.Join(dir, "report.xml"),
+	}
+
+	require.NoError(t, Write(t.Context(), config, results, nil))
+
+	for _, file := range []string{"report.sarif", "report.xml"} {
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		require.NoError(t, err)
+		require.NotEmpty(t, content)
+	}
+}
@@ -0,0 +1,162 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/Workday/cuestomize/pkg/cuestomize"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+)
+
+const (
+	// sarifVersion is the version of the SARIF format of the reports.
+	sarifVersion = "2.1.0"
+	// sarifSchema is the JSON schema of the SARIF format of the reports.
+	sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifToolName is the name of the tool in the SARIF reports.
+	sarifToolName = "cuestomize"
+	// constraintRuleID is the rule of the violations of the CUE model that are not policy violations.
+	constraintRuleID = "cue-constraint"
+)
+
+// sarifLog is the root object of a SARIF report.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID           string            `json:"ruleId"`
+	Level            string            `json:"level"`
+	Message          sarifMessage      `json:"message"`
+	Locations        []sarifLocation   `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation   `json:"relatedLocations,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// encodeSARIF writes the results as a SARIF report, with a rule for each violated policy (and one for the
+// other constraints of the CUE model).
+// The location of a result is the file of the offending resource, if known, and its identity, while the
+// violated constraint of the CUE model is a related location.
+func encodeSARIF(w io.Writer, results framework.Results) error {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: sarifToolName}},
+		Results: make([]sarifResult, 0, len(results)),
+	}
+	rules := make(map[string]bool)
+	for _, result := range results {
+		ruleID := constraintRuleID
+		if policy, ok := result.Tags[cuestomize.PolicyTag]; ok {
+			ruleID = policy
+		}
+		if !rules[ruleID] {
+			rules[ruleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: ruleID})
+		}
+		run.Results = append(run.Results, sarifResultOf(ruleID, result))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{Version: sarifVersion, Schema: sarifSchema, Runs: []sarifRun{run}})
+}
+
+// sarifResultOf converts a result into a SARIF result of the given rule.
+func sarifResultOf(ruleID string, result *framework.Result) sarifResult {
+	sarif := sarifResult{
+		RuleID:  ruleID,
+		Level:   sarifLevel(result.Severity),
+		Message: sarifMessage{Text: result.Message},
+	}
+
+	var location sarifLocation
+	if result.File != nil {
+		location.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: result.File.Path}}
+	}
+	if result.ResourceRef != nil {
+		location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: resourceName(result.ResourceRef), Kind: "resource"}}
+	}
+
+	var constraint *sarifPhysicalLocation
+	if pos, ok := parsePosition(result.Tags[cuestomize.CUEPositionTag]); ok {
+		constraint = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: pos.file},
+			Region:           &sarifRegion{StartLine: pos.line, StartColumn: pos.column},
+		}
+	}
+	switch {
+	case location.PhysicalLocation == nil && location.LogicalLocations == nil:
+		// violations of the model itself are located at the violated constraint
+		location.PhysicalLocation = constraint
+	case constraint != nil:
+		sarif.RelatedLocations = []sarifLocation{{PhysicalLocation: constraint, Message: &sarifMessage{Text: "violated constraint"}}}
+	}
+	if location.PhysicalLocation != nil || location.LogicalLocations != nil {
+		sarif.Locations = []sarifLocation{location}
+	}
+
+	if result.Field != nil && result.Field.Path != "" {
+		sarif.Properties = map[string]string{"field": result.Field.Path}
+	}
+	return sarif
+}
+
+// sarifLevel returns the SARIF level of a result severity.
+func sarifLevel(severity framework.Severity) string {
+	switch severity {
+	case framework.Warning:
+		return "warning"
+	case framework.Info:
+		return "note"
+	default:
+		return "error"
+	}
+}
@@ -8,6 +8,7 @@ input: {
 	namespace!:      string
 	image!:          string
 	replicas:        int | *1
+	ports?: [...{...}]
 }
 
 includes: {} | null
@@ -37,6 +38,9 @@ outDeployment: {
 				containers: [{
 					name:  "nginx"
 					image: input.image
+					if input.ports != _|_ {
+						ports: input.ports
+					}
 				}]
 			}
 		}
@@ -0,0 +1,17 @@
+package worker
+
+apiVersion: "cuestomize.dev/v1alpha1"
+kind:       "Cuestomization"
+
+input: {}
+
+includes: _
+
+outputs: cm: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	metadata: {
+		name:      "worker-config"
+		namespace: "default"
+	}
+}
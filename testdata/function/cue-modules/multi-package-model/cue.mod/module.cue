@@ -0,0 +1,4 @@
+module: "multipackage.cuestomize.dev"
+language: {
+	version: "v0.12.0"
+}
@@ -0,0 +1,17 @@
+package beta
+
+apiVersion: "cuestomize.dev/v1alpha1"
+kind:       "Cuestomization"
+
+input: {}
+
+includes: _
+
+outputs: cm: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	metadata: {
+		name:      "beta-config"
+		namespace: "default"
+	}
+}
@@ -0,0 +1,4 @@
+module: "ownership.cuestomize.dev"
+language: {
+	version: "v0.12.0"
+}
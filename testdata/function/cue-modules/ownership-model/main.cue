@@ -0,0 +1,19 @@
+package main
+
+input: [string]: string
+
+includes: _
+
+// the generated ConfigMap matches the include and inputFrom selectors of the function config
+outputs: summary: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	metadata: {
+		name:      "summary"
+		namespace: "default"
+	}
+	data: {
+		for name, _ in includes["v1"]["ConfigMap"]["default"] {"include-\(name)": "true"}
+		for key, value in input {"input-\(key)": value}
+	}
+}
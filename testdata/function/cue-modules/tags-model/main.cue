@@ -0,0 +1,23 @@
+package main
+
+apiVersion: "cuestomize.dev/v1alpha1"
+kind:       "Cuestomization"
+
+input: {}
+
+includes: _
+
+environment: "dev" | "prod" @tag(environment)
+
+// replicaCount is set by the environment-specific files, selected with build tags
+replicaCount: int
+
+outputs: cm: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	metadata: {
+		name:      "\(environment)-config"
+		namespace: "default"
+	}
+	data: replicas: "\(replicaCount)"
+}
@@ -0,0 +1,5 @@
+@if(prod)
+
+package main
+
+replicaCount: 3
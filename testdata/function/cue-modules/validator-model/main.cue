@@ -0,0 +1,29 @@
+package main
+
+apiVersion: "cuestomize.dev/v1alpha1"
+kind:       "Validator"
+
+input: {}
+
+includes: _
+
+// every included deployment must have between 2 and 4 replicas
+includes: "apps/v1": Deployment: [string]: [string]: spec: replicas: >=2 & <=4
+
+results: [{
+	message:  "deployments validated"
+	severity: "info"
+}]
+
+// deployments are labelled with their team, platform by default
+#policies: deployments: {
+	selector: {group: "apps", kind: "Deployment"}
+	mutation: "additive"
+	definition: {
+		metadata: {
+			labels: {team: *"platform" | string, ...}
+			...
+		}
+		...
+	}
+}
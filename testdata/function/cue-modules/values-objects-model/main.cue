@@ -0,0 +1,22 @@
+package main
+
+apiVersion: "cuestomize.dev/v1alpha1"
+kind:       "Cuestomization"
+
+values: {
+	configMapName!: string
+}
+
+resources: _
+
+objects: configmap: {
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	metadata: {
+		name:      values.configMapName
+		namespace: "default"
+	}
+	data: {
+		deploymentName: resources["apps/v1"]["Deployment"]["example-namespace"]["example-deployment"].metadata.name
+	}
+}